@@ -2,12 +2,17 @@ package ast
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/jpiechowka/micron-language-interpreter-go/token"
+	"strings"
+	"unicode"
 )
 
 type AstNode interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the first character belonging to the node
+	End() token.Position // position immediately after the last character belonging to the node
 }
 
 type Statement interface {
@@ -24,6 +29,20 @@ type Program struct {
 	Statements []Statement
 }
 
+func (program *Program) Pos() token.Position {
+	if len(program.Statements) > 0 {
+		return program.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+func (program *Program) End() token.Position {
+	if len(program.Statements) > 0 {
+		return program.Statements[len(program.Statements)-1].End()
+	}
+	return token.Position{}
+}
+
 func (program *Program) TokenLiteral() string {
 	if len(program.Statements) > 0 {
 		return program.Statements[0].TokenLiteral()
@@ -32,14 +51,23 @@ func (program *Program) TokenLiteral() string {
 	}
 }
 
+// LetStatement binds a name to a value. It is also used for constants, which are declared with the 'const'
+// keyword instead of 'let'.
 type LetStatement struct {
-	Token token.Token
+	Token token.Token // The 'let' or 'const' token
 	Name  *Identifier
 	Value Expression
 }
 
+// IsConst reports whether the statement declares a constant
+func (letStatement *LetStatement) IsConst() bool { return letStatement.Token.TokenType == token.CONST }
+
 func (letStatement *LetStatement) statementNode()       {}
 func (letStatement *LetStatement) TokenLiteral() string { return letStatement.Token.Literal }
+func (letStatement *LetStatement) Pos() token.Position  { return letStatement.Token.Pos }
+func (letStatement *LetStatement) End() token.Position {
+	return endOf(letStatement.Value, letStatement.Name.End())
+}
 
 type Identifier struct {
 	Token token.Token
@@ -49,6 +77,8 @@ type Identifier struct {
 func (identifier *Identifier) expressionNode()      {}
 func (identifier *Identifier) TokenLiteral() string { return identifier.Token.Literal }
 func (identifier *Identifier) String() string       { return identifier.Value }
+func (identifier *Identifier) Pos() token.Position  { return identifier.Token.Pos }
+func (identifier *Identifier) End() token.Position  { return identifier.Token.End }
 
 type ReturnStatement struct {
 	Token       token.Token
@@ -57,6 +87,10 @@ type ReturnStatement struct {
 
 func (returnStatement *ReturnStatement) statementNode()       {}
 func (returnStatement *ReturnStatement) TokenLiteral() string { return returnStatement.Token.Literal }
+func (returnStatement *ReturnStatement) Pos() token.Position  { return returnStatement.Token.Pos }
+func (returnStatement *ReturnStatement) End() token.Position {
+	return endOf(returnStatement.ReturnValue, returnStatement.Token.End)
+}
 
 type ExpressionStatement struct {
 	Token      token.Token // the first token of the expression
@@ -67,6 +101,103 @@ func (expressionStatement *ExpressionStatement) statementNode() {}
 func (expressionStatement *ExpressionStatement) TokenLiteral() string {
 	return expressionStatement.Token.Literal
 }
+func (expressionStatement *ExpressionStatement) Pos() token.Position {
+	return posOf(expressionStatement.Expression, expressionStatement.Token.Pos)
+}
+func (expressionStatement *ExpressionStatement) End() token.Position {
+	return endOf(expressionStatement.Expression, expressionStatement.Token.End)
+}
+
+// BlockStatement is a sequence of statements enclosed in braces, e.g. the body of a function.
+type BlockStatement struct {
+	Token      token.Token // the { token
+	Statements []Statement
+	Rbrace     token.Token // the } token
+}
+
+func (blockStatement *BlockStatement) statementNode()       {}
+func (blockStatement *BlockStatement) TokenLiteral() string { return blockStatement.Token.Literal }
+func (blockStatement *BlockStatement) Pos() token.Position  { return blockStatement.Token.Pos }
+func (blockStatement *BlockStatement) End() token.Position  { return blockStatement.Rbrace.End }
+func (blockStatement *BlockStatement) String() string {
+	var out bytes.Buffer
+
+	for _, statement := range blockStatement.Statements {
+		out.WriteString(statement.String())
+	}
+
+	return out.String()
+}
+
+type WhileStatement struct {
+	Token     token.Token // The 'while' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (whileStatement *WhileStatement) statementNode()       {}
+func (whileStatement *WhileStatement) TokenLiteral() string { return whileStatement.Token.Literal }
+func (whileStatement *WhileStatement) Pos() token.Position  { return whileStatement.Token.Pos }
+func (whileStatement *WhileStatement) End() token.Position  { return whileStatement.Body.End() }
+func (whileStatement *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while (")
+	out.WriteString(whileStatement.Condition.String())
+	out.WriteString(") ")
+	out.WriteString(whileStatement.Body.String())
+
+	return out.String()
+}
+
+// ForStatement is a loop like for (element in iterable) { ... }, binding each element of the iterable to Variable.
+type ForStatement struct {
+	Token    token.Token // The 'for' token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (forStatement *ForStatement) statementNode()       {}
+func (forStatement *ForStatement) TokenLiteral() string { return forStatement.Token.Literal }
+func (forStatement *ForStatement) Pos() token.Position  { return forStatement.Token.Pos }
+func (forStatement *ForStatement) End() token.Position  { return forStatement.Body.End() }
+func (forStatement *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	out.WriteString(forStatement.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(forStatement.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(forStatement.Body.String())
+
+	return out.String()
+}
+
+type BreakStatement struct {
+	Token token.Token // The 'break' token
+}
+
+func (breakStatement *BreakStatement) statementNode()       {}
+func (breakStatement *BreakStatement) TokenLiteral() string { return breakStatement.Token.Literal }
+func (breakStatement *BreakStatement) String() string       { return breakStatement.Token.Literal + ";" }
+func (breakStatement *BreakStatement) Pos() token.Position  { return breakStatement.Token.Pos }
+func (breakStatement *BreakStatement) End() token.Position  { return breakStatement.Token.End }
+
+type ContinueStatement struct {
+	Token token.Token // The 'continue' token
+}
+
+func (continueStatement *ContinueStatement) statementNode() {}
+func (continueStatement *ContinueStatement) TokenLiteral() string {
+	return continueStatement.Token.Literal
+}
+func (continueStatement *ContinueStatement) String() string {
+	return continueStatement.Token.Literal + ";"
+}
+func (continueStatement *ContinueStatement) Pos() token.Position { return continueStatement.Token.Pos }
+func (continueStatement *ContinueStatement) End() token.Position { return continueStatement.Token.End }
 
 type IntegerLiteral struct {
 	Token token.Token
@@ -76,6 +207,30 @@ type IntegerLiteral struct {
 func (integerLiteral *IntegerLiteral) expressionNode()      {}
 func (integerLiteral *IntegerLiteral) TokenLiteral() string { return integerLiteral.Token.Literal }
 func (integerLiteral *IntegerLiteral) String() string       { return integerLiteral.Token.Literal }
+func (integerLiteral *IntegerLiteral) Pos() token.Position  { return integerLiteral.Token.Pos }
+func (integerLiteral *IntegerLiteral) End() token.Position  { return integerLiteral.Token.End }
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (floatLiteral *FloatLiteral) expressionNode()      {}
+func (floatLiteral *FloatLiteral) TokenLiteral() string { return floatLiteral.Token.Literal }
+func (floatLiteral *FloatLiteral) String() string       { return floatLiteral.Token.Literal }
+func (floatLiteral *FloatLiteral) Pos() token.Position  { return floatLiteral.Token.Pos }
+func (floatLiteral *FloatLiteral) End() token.Position  { return floatLiteral.Token.End }
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (stringLiteral *StringLiteral) expressionNode()      {}
+func (stringLiteral *StringLiteral) TokenLiteral() string { return stringLiteral.Token.Literal }
+func (stringLiteral *StringLiteral) String() string       { return quote(stringLiteral.Value) }
+func (stringLiteral *StringLiteral) Pos() token.Position  { return stringLiteral.Token.Pos }
+func (stringLiteral *StringLiteral) End() token.Position  { return stringLiteral.Token.End }
 
 type PrefixExpression struct {
 	Token    token.Token // The prefix token, e.g. !
@@ -87,6 +242,10 @@ func (prefixExpression *PrefixExpression) expressionNode() {}
 func (prefixExpression *PrefixExpression) TokenLiteral() string {
 	return prefixExpression.Token.Literal
 }
+func (prefixExpression *PrefixExpression) Pos() token.Position { return prefixExpression.Token.Pos }
+func (prefixExpression *PrefixExpression) End() token.Position {
+	return endOf(prefixExpression.Right, prefixExpression.Token.End)
+}
 func (prefixExpression *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -105,6 +264,12 @@ type InfixExpression struct {
 
 func (infixExpression *InfixExpression) expressionNode()      {}
 func (infixExpression *InfixExpression) TokenLiteral() string { return infixExpression.Token.Literal }
+func (infixExpression *InfixExpression) Pos() token.Position {
+	return posOf(infixExpression.Left, infixExpression.Token.Pos)
+}
+func (infixExpression *InfixExpression) End() token.Position {
+	return endOf(infixExpression.Right, infixExpression.Token.End)
+}
 func (infixExpression *InfixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -123,6 +288,260 @@ type Boolean struct {
 func (boolean *Boolean) expressionNode()      {}
 func (boolean *Boolean) TokenLiteral() string { return boolean.Token.Literal }
 func (boolean *Boolean) String() string       { return boolean.Token.Literal }
+func (boolean *Boolean) Pos() token.Position  { return boolean.Token.Pos }
+func (boolean *Boolean) End() token.Position  { return boolean.Token.End }
+
+type IfExpression struct {
+	Token       token.Token // The 'if' token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement
+}
+
+func (ifExpression *IfExpression) expressionNode()      {}
+func (ifExpression *IfExpression) TokenLiteral() string { return ifExpression.Token.Literal }
+func (ifExpression *IfExpression) Pos() token.Position  { return ifExpression.Token.Pos }
+func (ifExpression *IfExpression) End() token.Position {
+	if ifExpression.Alternative != nil {
+		return ifExpression.Alternative.End()
+	}
+	return ifExpression.Consequence.End()
+}
+func (ifExpression *IfExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("if")
+	out.WriteString(ifExpression.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ifExpression.Consequence.String())
+
+	if ifExpression.Alternative != nil {
+		out.WriteString("else ")
+		out.WriteString(ifExpression.Alternative.String())
+	}
+
+	return out.String()
+}
+
+type FunctionLiteral struct {
+	Token      token.Token // The 'fn' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (functionLiteral *FunctionLiteral) expressionNode()      {}
+func (functionLiteral *FunctionLiteral) TokenLiteral() string { return functionLiteral.Token.Literal }
+func (functionLiteral *FunctionLiteral) Pos() token.Position  { return functionLiteral.Token.Pos }
+func (functionLiteral *FunctionLiteral) End() token.Position  { return functionLiteral.Body.End() }
+func (functionLiteral *FunctionLiteral) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, param := range functionLiteral.Parameters {
+		params = append(params, param.String())
+	}
+
+	out.WriteString(functionLiteral.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(functionLiteral.Body.String())
+
+	return out.String()
+}
+
+type CallExpression struct {
+	Token     token.Token // The '(' token
+	Function  Expression  // Identifier or FunctionLiteral
+	Arguments []Expression
+	Rparen    token.Token // The ')' token
+}
+
+func (callExpression *CallExpression) expressionNode()      {}
+func (callExpression *CallExpression) TokenLiteral() string { return callExpression.Token.Literal }
+func (callExpression *CallExpression) Pos() token.Position  { return callExpression.Function.Pos() }
+func (callExpression *CallExpression) End() token.Position  { return callExpression.Rparen.End }
+func (callExpression *CallExpression) String() string {
+	var out bytes.Buffer
+
+	args := []string{}
+	for _, arg := range callExpression.Arguments {
+		args = append(args, arg.String())
+	}
+
+	out.WriteString(callExpression.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type ArrayLiteral struct {
+	Token    token.Token // The '[' token
+	Elements []Expression
+	Rbracket token.Token // The ']' token
+}
+
+func (arrayLiteral *ArrayLiteral) expressionNode()      {}
+func (arrayLiteral *ArrayLiteral) TokenLiteral() string { return arrayLiteral.Token.Literal }
+func (arrayLiteral *ArrayLiteral) Pos() token.Position  { return arrayLiteral.Token.Pos }
+func (arrayLiteral *ArrayLiteral) End() token.Position  { return arrayLiteral.Rbracket.End }
+func (arrayLiteral *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, element := range arrayLiteral.Elements {
+		elements = append(elements, element.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type IndexExpression struct {
+	Token    token.Token // The '[' token
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token // The ']' token
+}
+
+func (indexExpression *IndexExpression) expressionNode()      {}
+func (indexExpression *IndexExpression) TokenLiteral() string { return indexExpression.Token.Literal }
+func (indexExpression *IndexExpression) Pos() token.Position  { return indexExpression.Left.Pos() }
+func (indexExpression *IndexExpression) End() token.Position  { return indexExpression.Rbracket.End }
+func (indexExpression *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(indexExpression.Left.String())
+	out.WriteString("[")
+	out.WriteString(indexExpression.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// SliceExpression is an expression like a[low:high]. Both bounds are optional and nil when omitted.
+type SliceExpression struct {
+	Token    token.Token // The '[' token
+	Left     Expression
+	Low      Expression
+	High     Expression
+	Rbracket token.Token // The ']' token
+}
+
+func (sliceExpression *SliceExpression) expressionNode()      {}
+func (sliceExpression *SliceExpression) TokenLiteral() string { return sliceExpression.Token.Literal }
+func (sliceExpression *SliceExpression) Pos() token.Position  { return sliceExpression.Left.Pos() }
+func (sliceExpression *SliceExpression) End() token.Position  { return sliceExpression.Rbracket.End }
+func (sliceExpression *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(sliceExpression.Left.String())
+	out.WriteString("[")
+	if sliceExpression.Low != nil {
+		out.WriteString(sliceExpression.Low.String())
+	}
+	out.WriteString(":")
+	if sliceExpression.High != nil {
+		out.WriteString(sliceExpression.High.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// AssignExpression assigns to an existing binding or to an element of an array or hash. Compound assignments like
+// x += 1 keep their operator, the plain assignment has "=" as the operator.
+type AssignExpression struct {
+	Token    token.Token // The assignment operator token
+	Target   Expression  // Identifier or IndexExpression
+	Operator string
+	Value    Expression
+}
+
+func (assignExpression *AssignExpression) expressionNode() {}
+func (assignExpression *AssignExpression) TokenLiteral() string {
+	return assignExpression.Token.Literal
+}
+func (assignExpression *AssignExpression) Pos() token.Position { return assignExpression.Target.Pos() }
+func (assignExpression *AssignExpression) End() token.Position {
+	return endOf(assignExpression.Value, assignExpression.Token.End)
+}
+func (assignExpression *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(assignExpression.Target.String())
+	out.WriteString(" " + assignExpression.Operator + " ")
+	out.WriteString(assignExpression.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// HashLiteral keeps its pairs in the order they appear in the source code, which is also the order of the keys
+// in the hash it evaluates to.
+type HashLiteral struct {
+	Token  token.Token // The '{' token
+	Pairs  []HashPair
+	Rbrace token.Token // The '}' token
+}
+
+func (hashLiteral *HashLiteral) expressionNode()      {}
+func (hashLiteral *HashLiteral) TokenLiteral() string { return hashLiteral.Token.Literal }
+func (hashLiteral *HashLiteral) Pos() token.Position  { return hashLiteral.Token.Pos }
+func (hashLiteral *HashLiteral) End() token.Position  { return hashLiteral.Rbrace.End }
+func (hashLiteral *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hashLiteral.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// BadStatement is a placeholder for a statement containing syntax errors which could not be parsed.
+type BadStatement struct {
+	Token token.Token // the first token of the statement
+	From  token.Position
+	To    token.Position
+}
+
+func (badStatement *BadStatement) statementNode()       {}
+func (badStatement *BadStatement) TokenLiteral() string { return badStatement.Token.Literal }
+func (badStatement *BadStatement) String() string       { return "<bad statement>" }
+func (badStatement *BadStatement) Pos() token.Position  { return badStatement.From }
+func (badStatement *BadStatement) End() token.Position  { return badStatement.To }
+
+// BadExpression is a placeholder for an expression containing syntax errors which could not be parsed.
+type BadExpression struct {
+	Token token.Token // the first token of the expression
+	From  token.Position
+	To    token.Position
+}
+
+func (badExpression *BadExpression) expressionNode()      {}
+func (badExpression *BadExpression) TokenLiteral() string { return badExpression.Token.Literal }
+func (badExpression *BadExpression) String() string       { return "<bad expression>" }
+func (badExpression *BadExpression) Pos() token.Position  { return badExpression.From }
+func (badExpression *BadExpression) End() token.Position  { return badExpression.To }
 
 func (program *Program) String() string {
 	var out bytes.Buffer
@@ -171,3 +590,51 @@ func (expressionStatement *ExpressionStatement) String() string {
 
 	return ""
 }
+
+// posOf returns the start of the node, or the fallback position if the node is missing because of a parse error.
+func posOf(node AstNode, fallback token.Position) token.Position {
+	if node == nil {
+		return fallback
+	}
+	return node.Pos()
+}
+
+// endOf returns the end of the node, or the fallback position if the node is missing because of a parse error.
+func endOf(node AstNode, fallback token.Position) token.Position {
+	if node == nil {
+		return fallback
+	}
+	return node.End()
+}
+
+// quote formats the string as a Micron string literal, escaping the characters which cannot appear in it as is
+func quote(value string) string {
+	var out bytes.Buffer
+
+	out.WriteByte('"')
+
+	for _, char := range value {
+		switch char {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\r':
+			out.WriteString(`\r`)
+		default:
+			if unicode.IsPrint(char) {
+				out.WriteRune(char)
+			} else {
+				fmt.Fprintf(&out, "\\u{%X}", char)
+			}
+		}
+	}
+
+	out.WriteByte('"')
+
+	return out.String()
+}
@@ -20,3 +20,60 @@ func TestString(t *testing.T) {
 		t.Errorf("AST test string failed - program.String() returned wrong values. Got %s", program.String())
 	}
 }
+
+func TestLoopString(t *testing.T) {
+	identifier := func(name string) *Identifier {
+		return &Identifier{Token: token.Token{TokenType: token.IDENT, Literal: name}, Value: name}
+	}
+	body := &BlockStatement{
+		Token:      token.Token{TokenType: token.LBRACE, Literal: "{"},
+		Statements: []Statement{&BreakStatement{Token: token.Token{TokenType: token.BREAK, Literal: "break"}}},
+	}
+
+	tests := []struct {
+		statement Statement
+		expected  string
+	}{
+		{
+			&WhileStatement{Token: token.Token{TokenType: token.WHILE, Literal: "while"}, Condition: identifier("ok"), Body: body},
+			"while (ok) break;",
+		},
+		{
+			&ForStatement{
+				Token:    token.Token{TokenType: token.FOR, Literal: "for"},
+				Variable: identifier("x"),
+				Iterable: identifier("xs"),
+				Body:     body,
+			},
+			"for (x in xs) break;",
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.statement.String() != tt.expected {
+			t.Errorf("Loop String() returned wrong value. Expected %s, got %s", tt.expected, tt.statement.String())
+		}
+	}
+}
+
+func TestStringLiteralQuoting(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"plain", `"plain"`},
+		{"say \"hi\"", `"say \"hi\""`},
+		{"a\\b", `"a\\b"`},
+		{"line\nbreak\ttab", `"line\nbreak\ttab"`},
+		{"zażółć", `"zażółć"`},
+		{"\x00\x1b", `"\u{0}\u{1B}"`},
+	}
+
+	for _, tt := range tests {
+		literal := &StringLiteral{Token: token.Token{TokenType: token.STRING, Literal: tt.value}, Value: tt.value}
+
+		if literal.String() != tt.expected {
+			t.Errorf("StringLiteral.String() returned wrong value. Expected %s, got %s", tt.expected, literal.String())
+		}
+	}
+}
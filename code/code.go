@@ -0,0 +1,282 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Instructions is a sequence of encoded instructions. Every instruction starts with a one byte opcode followed by
+// its operands, which are encoded in big endian with the widths given by the definition of the opcode.
+type Instructions []byte
+
+// String disassembles the instructions, one instruction per line prefixed with its offset
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		definition, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "%04d ERROR: %s\n", i, err)
+			i++
+			continue
+		}
+
+		width := 0
+		for _, operandWidth := range definition.OperandWidths {
+			width += operandWidth
+		}
+
+		if i+1+width > len(ins) {
+			fmt.Fprintf(&out, "%04d ERROR: %s is missing operands\n", i, definition.Name)
+			break
+		}
+
+		operands, read := ReadOperands(definition, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s\n", i, FormatInstruction(Opcode(ins[i]), definition, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+// FormatInstruction prints the name of the instruction followed by its operands
+func FormatInstruction(op Opcode, definition *Definition, operands []int) string {
+	formatted := []string{definition.Name}
+
+	for _, operand := range operands {
+		formatted = append(formatted, fmt.Sprintf("%d", operand))
+	}
+
+	// The operand of OpUpdateIndex is an opcode itself
+	if op == OpUpdateIndex {
+		if operator, err := Lookup(byte(operands[0])); err == nil {
+			formatted[1] = operator.Name
+		}
+	}
+
+	return strings.Join(formatted, " ")
+}
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota // push the constant at the index
+	OpPop                    // discard the value on top of the stack
+	OpTrue
+	OpFalse
+	OpNull
+
+	// Infix operators, they pop the right and then the left operand and push the result
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+	OpBitAnd
+	OpBitOr
+	OpBitXor
+	OpShiftLeft
+	OpShiftRight
+	OpEqual
+	OpNotEqual
+	OpLessThan
+	OpLessThanOrEqual
+	OpGreaterThan
+	OpGreaterThanOrEqual
+	OpRange
+
+	// Prefix operators, they replace the value on top of the stack
+	OpMinus
+	OpBang
+	OpBitNot
+
+	// Jumps to an absolute offset in the instructions of the current function
+	OpJump
+	OpJumpNotTruthy // pops the condition
+	OpJumpTruthy    // pops the condition
+
+	// Variables. Defining a variable always succeeds, setting it requires it to be defined already. Both pop the value.
+	OpGetGlobal
+	OpSetGlobal
+	OpDefineGlobal
+	OpGetLocal
+	OpSetLocal
+	OpDefineLocal
+	OpGetFree // variable of an enclosing scope, the first operand is the number of scopes to go out
+	OpSetFree
+	OpCheckGlobal // fail unless the variable is defined, done before computing the value assigned to it
+	OpCheckLocal
+	OpCheckFree
+
+	// Every iteration of a loop body runs in a new scope, so closures created in it capture the variables of that
+	// iteration. The operand is the index of the scope in the scopes of the current function.
+	OpEnterScope
+	OpLeaveScope
+
+	OpFreeze // replace the value on top of the stack with an immutable copy, used for constants
+
+	OpArray       // the operand is the number of elements on the stack
+	OpHash        // the operand is the number of keys and values on the stack, in key, value order
+	OpIndex       // pops the index and the indexed value
+	OpSetIndex    // pops the value, the index and the indexed value and pushes the value
+	OpUpdateIndex // like OpSetIndex, but combines the value with the current one using the operator opcode operand
+	OpSlice       // pops the bounds given by the operand flags and the sliced value
+
+	OpIterInit // replace the iterable on top of the stack by an iterator
+	OpIterNext // push the next value of the iterator, or pop the iterator and jump if it is exhausted
+
+	OpClosure     // the operand is the index of the compiled function in the constants
+	OpCall        // the operand is the number of arguments, which are on the stack above the called function
+	OpReturnValue // return the value on top of the stack
+	OpReturn      // return null
+)
+
+// Flags of the OpSlice operand telling which bounds are on the stack
+const (
+	SliceLow  = 1
+	SliceHigh = 2
+)
+
+type Definition struct {
+	Name          string
+	OperandWidths []int // number of bytes each operand takes
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+	OpNull:     {"OpNull", []int{}},
+
+	OpAdd:                {"OpAdd", []int{}},
+	OpSub:                {"OpSub", []int{}},
+	OpMul:                {"OpMul", []int{}},
+	OpDiv:                {"OpDiv", []int{}},
+	OpMod:                {"OpMod", []int{}},
+	OpPow:                {"OpPow", []int{}},
+	OpBitAnd:             {"OpBitAnd", []int{}},
+	OpBitOr:              {"OpBitOr", []int{}},
+	OpBitXor:             {"OpBitXor", []int{}},
+	OpShiftLeft:          {"OpShiftLeft", []int{}},
+	OpShiftRight:         {"OpShiftRight", []int{}},
+	OpEqual:              {"OpEqual", []int{}},
+	OpNotEqual:           {"OpNotEqual", []int{}},
+	OpLessThan:           {"OpLessThan", []int{}},
+	OpLessThanOrEqual:    {"OpLessThanOrEqual", []int{}},
+	OpGreaterThan:        {"OpGreaterThan", []int{}},
+	OpGreaterThanOrEqual: {"OpGreaterThanOrEqual", []int{}},
+	OpRange:              {"OpRange", []int{}},
+
+	OpMinus:  {"OpMinus", []int{}},
+	OpBang:   {"OpBang", []int{}},
+	OpBitNot: {"OpBitNot", []int{}},
+
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJumpTruthy:    {"OpJumpTruthy", []int{2}},
+
+	OpGetGlobal:    {"OpGetGlobal", []int{2}},
+	OpSetGlobal:    {"OpSetGlobal", []int{2}},
+	OpDefineGlobal: {"OpDefineGlobal", []int{2}},
+	OpGetLocal:     {"OpGetLocal", []int{1}},
+	OpSetLocal:     {"OpSetLocal", []int{1}},
+	OpDefineLocal:  {"OpDefineLocal", []int{1}},
+	OpGetFree:      {"OpGetFree", []int{1, 1}},
+	OpSetFree:      {"OpSetFree", []int{1, 1}},
+	OpCheckGlobal:  {"OpCheckGlobal", []int{2}},
+	OpCheckLocal:   {"OpCheckLocal", []int{1}},
+	OpCheckFree:    {"OpCheckFree", []int{1, 1}},
+
+	OpEnterScope: {"OpEnterScope", []int{2}},
+	OpLeaveScope: {"OpLeaveScope", []int{}},
+
+	OpFreeze: {"OpFreeze", []int{}},
+
+	OpArray:       {"OpArray", []int{2}},
+	OpHash:        {"OpHash", []int{2}},
+	OpIndex:       {"OpIndex", []int{}},
+	OpSetIndex:    {"OpSetIndex", []int{}},
+	OpUpdateIndex: {"OpUpdateIndex", []int{1}},
+	OpSlice:       {"OpSlice", []int{1}},
+
+	OpIterInit: {"OpIterInit", []int{}},
+	OpIterNext: {"OpIterNext", []int{2}},
+
+	OpClosure:     {"OpClosure", []int{2}},
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+}
+
+func Lookup(op byte) (*Definition, error) {
+	definition, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("Opcode %d undefined", op)
+	}
+
+	return definition, nil
+}
+
+// Make encodes the instruction, an unknown opcode results in an empty instruction. The operands have to fit their
+// widths, which the compiler checks before encoding them.
+func Make(op Opcode, operands ...int) []byte {
+	definition, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLength := 1
+	for _, width := range definition.OperandWidths {
+		instructionLength += width
+	}
+
+	instruction := make([]byte, instructionLength)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, operand := range operands {
+		width := definition.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(operand))
+		case 1:
+			instruction[offset] = byte(operand)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction, starting right after its opcode. It also returns the number
+// of bytes the operands took.
+func ReadOperands(definition *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(definition.OperandWidths))
+	offset := 0
+
+	for i, width := range definition.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
@@ -0,0 +1,124 @@
+package code
+
+import "testing"
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpGetLocal, []int{255}, []byte{byte(OpGetLocal), 255}},
+		{OpGetFree, []int{2, 7}, []byte{byte(OpGetFree), 2, 7}},
+		{OpSlice, []int{SliceLow | SliceHigh}, []byte{byte(OpSlice), 3}},
+	}
+
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+
+		if len(instruction) != len(tt.expected) {
+			t.Errorf("Instruction has wrong length. Expected %d, got %d instead", len(tt.expected), len(instruction))
+			continue
+		}
+
+		for i, b := range tt.expected {
+			if instruction[i] != b {
+				t.Errorf("Wrong byte at position %d. Expected %d, got %d instead", i, b, instruction[i])
+			}
+		}
+	}
+}
+
+func TestMakeUnknownOpcode(t *testing.T) {
+	if instruction := Make(Opcode(255)); len(instruction) != 0 {
+		t.Errorf("Make() of an unknown opcode is not empty. Got %v instead", instruction)
+	}
+}
+
+func TestReadOperands(t *testing.T) {
+	tests := []struct {
+		op        Opcode
+		operands  []int
+		bytesRead int
+	}{
+		{OpConstant, []int{65535}, 2},
+		{OpCall, []int{255}, 1},
+		{OpSetFree, []int{1, 255}, 2},
+		{OpPop, []int{}, 0},
+	}
+
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+
+		definition, err := Lookup(byte(tt.op))
+		if err != nil {
+			t.Fatalf("Definition not found: %s", err)
+		}
+
+		operandsRead, n := ReadOperands(definition, instruction[1:])
+		if n != tt.bytesRead {
+			t.Fatalf("Wrong number of bytes read. Expected %d, got %d instead", tt.bytesRead, n)
+		}
+
+		for i, expected := range tt.operands {
+			if operandsRead[i] != expected {
+				t.Errorf("Wrong operand %d. Expected %d, got %d instead", i, expected, operandsRead[i])
+			}
+		}
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	for op := OpConstant; op <= OpReturn; op++ {
+		if _, err := Lookup(byte(op)); err != nil {
+			t.Errorf("Opcode %d has no definition", op)
+		}
+	}
+
+	if _, err := Lookup(byte(OpReturn) + 1); err == nil {
+		t.Errorf("Lookup() of an undefined opcode did not fail")
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	instructions := []Instructions{
+		Make(OpAdd),
+		Make(OpGetLocal, 1),
+		Make(OpConstant, 2),
+		Make(OpConstant, 65535),
+		Make(OpGetFree, 1, 3),
+		Make(OpUpdateIndex, int(OpMul)),
+	}
+
+	expected := `0000 OpAdd
+0001 OpGetLocal 1
+0003 OpConstant 2
+0006 OpConstant 65535
+0009 OpGetFree 1 3
+0012 OpUpdateIndex OpMul
+`
+
+	concatenated := Instructions{}
+	for _, ins := range instructions {
+		concatenated = append(concatenated, ins...)
+	}
+
+	if concatenated.String() != expected {
+		t.Errorf("Instructions are wrongly formatted. Expected %q, got %q instead", expected, concatenated.String())
+	}
+}
+
+func TestInstructionsStringErrors(t *testing.T) {
+	instructions := Instructions{255, byte(OpPop), byte(OpConstant), 1}
+
+	expected := `0000 ERROR: Opcode 255 undefined
+0001 OpPop
+0002 ERROR: OpConstant is missing operands
+`
+
+	if instructions.String() != expected {
+		t.Errorf("Malformed instructions are wrongly formatted. Expected %q, got %q instead", expected, instructions.String())
+	}
+}
@@ -0,0 +1,755 @@
+package compiler
+
+import (
+	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/ast"
+	"github.com/jpiechowka/micron-language-interpreter-go/code"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+	"strings"
+)
+
+var prefixOperators = map[string]code.Opcode{
+	"-": code.OpMinus,
+	"!": code.OpBang,
+	"~": code.OpBitNot,
+}
+
+var infixOperators = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"**": code.OpPow,
+	"&":  code.OpBitAnd,
+	"|":  code.OpBitOr,
+	"^":  code.OpBitXor,
+	"<<": code.OpShiftLeft,
+	">>": code.OpShiftRight,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLessThan,
+	"<=": code.OpLessThanOrEqual,
+	">":  code.OpGreaterThan,
+	">=": code.OpGreaterThanOrEqual,
+	"..": code.OpRange,
+}
+
+// Bytecode is the compiled program, ready to be run by the virtual machine
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+	Scopes       [][]string                  // variable names of the loop bodies of the program by slot, see code.OpEnterScope
+	Globals      []string                    // names of the global variables by slot
+	Calls        map[int]*ast.CallExpression // see object.CompiledFunction
+}
+
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+// CompilationScope collects the instructions of the program or of a function literal
+type CompilationScope struct {
+	instructions    code.Instructions
+	lastInstruction EmittedInstruction
+	scopes          [][]string // variable names of the scopes of the function, see object.CompiledFunction
+	depth           int        // number of loop body scopes entered at the current instruction
+	operands        int        // number of operand values on the stack at the current instruction, see compileOperands
+	loops           []*loop
+	calls           map[int]*ast.CallExpression
+}
+
+// loop tracks the jump targets of break and continue statements in a loop body
+type loop struct {
+	start    int   // position continue jumps to
+	breaks   []int // positions of the jumps to the end of the loop, patched once the end is known
+	depth    int   // CompilationScope.depth outside of the loop body
+	operands int   // CompilationScope.operands outside of the loop body
+	iterator bool  // whether the loop keeps an iterator on the stack
+}
+
+type Compiler struct {
+	constants   []object.Object
+	symbolTable *SymbolTable
+	scopes      []*CompilationScope
+	err         error // set by the first operand which does not fit its width, see checkOperands
+}
+
+func New() *Compiler {
+	return &Compiler{
+		constants:   []object.Object{},
+		symbolTable: NewSymbolTable(),
+		scopes:      []*CompilationScope{{calls: map[int]*ast.CallExpression{}}},
+	}
+}
+
+func (compiler *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: compiler.currentScope().instructions,
+		Constants:    compiler.constants,
+		Scopes:       compiler.currentScope().scopes,
+		Globals:      compiler.symbolTable.Global().Names(),
+		Calls:        compiler.currentScope().calls,
+	}
+}
+
+func (compiler *Compiler) Compile(node ast.AstNode) error {
+	switch node := node.(type) {
+
+	// Statements
+	case *ast.Program:
+		compiler.hoist(node.Statements)
+		if err := compiler.compileStatements(node.Statements); err != nil {
+			return err
+		}
+
+		if endsWithLoop(node.Statements) {
+			// Like in the evaluator the value of a loop is null, which makes it the result of the program
+			compiler.emit(code.OpNull)
+			compiler.emit(code.OpPop)
+		}
+		return compiler.err
+	case *ast.ExpressionStatement:
+		if err := compiler.Compile(node.Expression); err != nil {
+			return err
+		}
+		compiler.emit(code.OpPop)
+	case *ast.BlockStatement:
+		return compiler.compileStatements(node.Statements)
+	case *ast.LetStatement:
+		if err := compiler.Compile(node.Value); err != nil {
+			return err
+		}
+
+		if node.IsConst() {
+			compiler.emit(code.OpFreeze)
+		}
+
+		symbol := compiler.symbolTable.Define(node.Name.Value, node.IsConst())
+		if symbol.Scope == GlobalScope {
+			compiler.emit(code.OpDefineGlobal, symbol.Index)
+		} else {
+			compiler.emit(code.OpDefineLocal, symbol.Index)
+		}
+	case *ast.BadStatement:
+		return fmt.Errorf("Cannot compile code containing syntax errors")
+	case *ast.ReturnStatement:
+		if err := compiler.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		compiler.emit(code.OpReturnValue)
+	case *ast.WhileStatement:
+		return compiler.compileWhileStatement(node)
+	case *ast.ForStatement:
+		return compiler.compileForStatement(node)
+	case *ast.BreakStatement:
+		return compiler.compileLoopJump(node.TokenLiteral(), true)
+	case *ast.ContinueStatement:
+		return compiler.compileLoopJump(node.TokenLiteral(), false)
+
+	// Expressions
+	case *ast.BadExpression:
+		return fmt.Errorf("Cannot compile code containing syntax errors")
+	case *ast.IntegerLiteral:
+		compiler.emit(code.OpConstant, compiler.addConstant(&object.Integer{Value: node.Value}))
+	case *ast.FloatLiteral:
+		compiler.emit(code.OpConstant, compiler.addConstant(&object.Float{Value: node.Value}))
+	case *ast.StringLiteral:
+		compiler.emit(code.OpConstant, compiler.addConstant(&object.String{Value: node.Value}))
+	case *ast.Boolean:
+		if node.Value {
+			compiler.emit(code.OpTrue)
+		} else {
+			compiler.emit(code.OpFalse)
+		}
+	case *ast.PrefixExpression:
+		op, ok := prefixOperators[node.Operator]
+		if !ok {
+			return fmt.Errorf("Unknown operator: %s", node.Operator)
+		}
+
+		if err := compiler.Compile(node.Right); err != nil {
+			return err
+		}
+		compiler.emit(op)
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return compiler.compileLogicalExpression(node)
+		}
+
+		op, ok := infixOperators[node.Operator]
+		if !ok {
+			return fmt.Errorf("Unknown operator: %s", node.Operator)
+		}
+
+		if err := compiler.compileOperands(node.Left, node.Right); err != nil {
+			return err
+		}
+		compiler.emit(op)
+	case *ast.IfExpression:
+		return compiler.compileIfExpression(node)
+	case *ast.Identifier:
+		compiler.loadSymbol(compiler.resolve(node.Value))
+	case *ast.FunctionLiteral:
+		return compiler.compileFunctionLiteral(node)
+	case *ast.CallExpression:
+		if len(node.Arguments) > 255 {
+			return fmt.Errorf("Too many arguments: %d", len(node.Arguments))
+		}
+
+		operands := append([]ast.Expression{node.Function}, node.Arguments...)
+		if err := compiler.compileOperands(operands...); err != nil {
+			return err
+		}
+		compiler.currentScope().calls[compiler.emit(code.OpCall, len(node.Arguments))] = node
+	case *ast.ArrayLiteral:
+		if err := compiler.compileOperands(node.Elements...); err != nil {
+			return err
+		}
+		compiler.emit(code.OpArray, len(node.Elements))
+	case *ast.HashLiteral:
+		operands := []ast.Expression{}
+		for _, pair := range node.Pairs {
+			operands = append(operands, pair.Key, pair.Value)
+		}
+		if err := compiler.compileOperands(operands...); err != nil {
+			return err
+		}
+		compiler.emit(code.OpHash, len(operands))
+	case *ast.IndexExpression:
+		if err := compiler.compileOperands(node.Left, node.Index); err != nil {
+			return err
+		}
+		compiler.emit(code.OpIndex)
+	case *ast.SliceExpression:
+		return compiler.compileSliceExpression(node)
+	case *ast.AssignExpression:
+		return compiler.compileAssignExpression(node)
+	default:
+		return fmt.Errorf("Cannot compile %T", node)
+	}
+
+	return nil
+}
+
+func (compiler *Compiler) compileStatements(statements []ast.Statement) error {
+	for _, statement := range statements {
+		if err := compiler.Compile(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileOperands compiles expressions whose values stay on the stack until the next instruction consumes them. The
+// values of the operands compiled so far are counted, so break and continue statements in the remaining operands
+// can discard them, see compileLoopJump.
+func (compiler *Compiler) compileOperands(expressions ...ast.Expression) error {
+	scope := compiler.currentScope()
+	operands := scope.operands
+	defer func() { scope.operands = operands }()
+
+	for _, expression := range expressions {
+		if err := compiler.Compile(expression); err != nil {
+			return err
+		}
+		scope.operands++
+	}
+	return nil
+}
+
+// compileBlockValue compiles a block used as a value. The value of the block is the value of its last statement
+// if it is an expression, and null otherwise.
+func (compiler *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	if err := compiler.compileStatements(block.Statements); err != nil {
+		return err
+	}
+
+	if endsWithExpression(block) {
+		compiler.removeLastInstruction() // Keep the value instead of popping it
+	} else {
+		compiler.emit(code.OpNull)
+	}
+
+	return nil
+}
+
+// compileLogicalExpression compiles && and || with short-circuiting. Like in the evaluator the result is always
+// a boolean based on the truthiness of the operands.
+func (compiler *Compiler) compileLogicalExpression(expression *ast.InfixExpression) error {
+	// && jumps to false as soon as an operand is falsy, || jumps to true as soon as an operand is truthy
+	jumpOp, fallthroughOp, shortCircuitOp := code.OpJumpNotTruthy, code.OpTrue, code.OpFalse
+	if expression.Operator == "||" {
+		jumpOp, fallthroughOp, shortCircuitOp = code.OpJumpTruthy, code.OpFalse, code.OpTrue
+	}
+
+	if err := compiler.Compile(expression.Left); err != nil {
+		return err
+	}
+	leftJump := compiler.emit(jumpOp, 9999)
+
+	if err := compiler.Compile(expression.Right); err != nil {
+		return err
+	}
+	rightJump := compiler.emit(jumpOp, 9999)
+
+	compiler.emit(fallthroughOp)
+	endJump := compiler.emit(code.OpJump, 9999)
+
+	shortCircuit := len(compiler.currentInstructions())
+	compiler.changeOperand(leftJump, shortCircuit)
+	compiler.changeOperand(rightJump, shortCircuit)
+	compiler.emit(shortCircuitOp)
+
+	compiler.changeOperand(endJump, len(compiler.currentInstructions()))
+	return nil
+}
+
+func (compiler *Compiler) compileIfExpression(ifExpression *ast.IfExpression) error {
+	if err := compiler.Compile(ifExpression.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthy := compiler.emit(code.OpJumpNotTruthy, 9999) // Placeholder, patched once the target is known
+
+	if err := compiler.compileBlockValue(ifExpression.Consequence); err != nil {
+		return err
+	}
+
+	jump := compiler.emit(code.OpJump, 9999)
+	compiler.changeOperand(jumpNotTruthy, len(compiler.currentInstructions()))
+
+	if ifExpression.Alternative == nil {
+		compiler.emit(code.OpNull)
+	} else if err := compiler.compileBlockValue(ifExpression.Alternative); err != nil {
+		return err
+	}
+
+	compiler.changeOperand(jump, len(compiler.currentInstructions()))
+	return nil
+}
+
+func (compiler *Compiler) compileFunctionLiteral(functionLiteral *ast.FunctionLiteral) error {
+	compiler.enterScope()
+
+	for _, param := range functionLiteral.Parameters {
+		compiler.symbolTable.DefineParameter(param.Value)
+	}
+	compiler.hoist(functionLiteral.Body.Statements)
+
+	if err := compiler.compileStatements(functionLiteral.Body.Statements); err != nil {
+		return err
+	}
+
+	if endsWithExpression(functionLiteral.Body) {
+		compiler.replaceLastInstruction(code.OpReturnValue) // The OpPop of the last expression statement
+	} else if !endsWithReturn(functionLiteral.Body) {
+		compiler.emit(code.OpReturn)
+	}
+
+	if len(compiler.symbolTable.Names()) > 256 {
+		return fmt.Errorf("Too many local variables: %d", len(compiler.symbolTable.Names()))
+	}
+
+	compiler.currentScope().scopes[0] = compiler.symbolTable.Names()
+	scope := compiler.leaveScope()
+
+	compiledFunction := &object.CompiledFunction{
+		Instructions:  scope.instructions,
+		NumParameters: len(functionLiteral.Parameters),
+		Scopes:        scope.scopes,
+		Calls:         scope.calls,
+		Literal:       functionLiteral,
+	}
+	compiler.emit(code.OpClosure, compiler.addConstant(compiledFunction))
+
+	return nil
+}
+
+func (compiler *Compiler) compileSliceExpression(slice *ast.SliceExpression) error {
+	operands := []ast.Expression{slice.Left}
+
+	flags := 0
+	if slice.Low != nil {
+		operands = append(operands, slice.Low)
+		flags |= code.SliceLow
+	}
+	if slice.High != nil {
+		operands = append(operands, slice.High)
+		flags |= code.SliceHigh
+	}
+
+	if err := compiler.compileOperands(operands...); err != nil {
+		return err
+	}
+	compiler.emit(code.OpSlice, flags)
+	return nil
+}
+
+// compileAssignExpression leaves the assigned value on the stack, as assignments are expressions
+func (compiler *Compiler) compileAssignExpression(assignment *ast.AssignExpression) error {
+	// The operator of a compound assignment without the trailing '=', empty for plain assignments
+	op, compound := infixOperators[strings.TrimSuffix(assignment.Operator, "=")]
+
+	switch target := assignment.Target.(type) {
+	case *ast.Identifier:
+		symbol := compiler.resolve(target.Value)
+		if symbol.Constant {
+			return fmt.Errorf("Cannot assign to constant %s", target.Value)
+		}
+
+		// Like in the evaluator, assigning to an undeclared variable fails before the value is computed
+		compiler.checkSymbol(symbol)
+		if compound {
+			compiler.loadSymbol(symbol)
+			compiler.currentScope().operands++ // The current value stays on the stack while the value is computed
+		}
+		if err := compiler.Compile(assignment.Value); err != nil {
+			return err
+		}
+		if compound {
+			compiler.currentScope().operands--
+			compiler.emit(op)
+		}
+
+		compiler.storeSymbol(symbol)
+		compiler.loadSymbol(symbol)
+	case *ast.IndexExpression:
+		if err := compiler.compileOperands(target.Left, target.Index, assignment.Value); err != nil {
+			return err
+		}
+
+		if compound {
+			compiler.emit(code.OpUpdateIndex, int(op))
+		} else {
+			compiler.emit(code.OpSetIndex)
+		}
+	default:
+		return fmt.Errorf("Cannot assign to %s", assignment.Target.String())
+	}
+
+	return nil
+}
+
+func (compiler *Compiler) compileWhileStatement(whileStatement *ast.WhileStatement) error {
+	start := len(compiler.currentInstructions())
+
+	if err := compiler.Compile(whileStatement.Condition); err != nil {
+		return err
+	}
+	exit := compiler.emit(code.OpJumpNotTruthy, 9999)
+
+	body := &loop{start: start}
+	if err := compiler.compileLoopBody(whileStatement.Body, body, nil); err != nil {
+		return err
+	}
+	compiler.emit(code.OpJump, start)
+
+	compiler.changeOperand(exit, len(compiler.currentInstructions()))
+	compiler.patchBreaks(body)
+	return nil
+}
+
+func (compiler *Compiler) compileForStatement(forStatement *ast.ForStatement) error {
+	if err := compiler.Compile(forStatement.Iterable); err != nil {
+		return err
+	}
+	compiler.emit(code.OpIterInit)
+	next := compiler.emit(code.OpIterNext, 9999)
+
+	body := &loop{start: next, iterator: true}
+	if err := compiler.compileLoopBody(forStatement.Body, body, forStatement.Variable); err != nil {
+		return err
+	}
+	compiler.emit(code.OpJump, next)
+
+	compiler.changeOperand(next, len(compiler.currentInstructions()))
+	compiler.patchBreaks(body)
+	return nil
+}
+
+// compileLoopBody compiles the body in a new scope, which is entered on every iteration. The loop variable, if
+// there is one, gets the first slot of the scope and is defined from the value on top of the stack.
+func (compiler *Compiler) compileLoopBody(block *ast.BlockStatement, body *loop, variable *ast.Identifier) error {
+	scope := compiler.currentScope()
+	compiler.symbolTable = NewEnclosedSymbolTable(compiler.symbolTable)
+	if variable != nil {
+		compiler.symbolTable.Define(variable.Value, false)
+	}
+	compiler.hoist(block.Statements)
+
+	index := len(scope.scopes)
+	scope.scopes = append(scope.scopes, nil)
+	compiler.emit(code.OpEnterScope, index)
+	if variable != nil {
+		compiler.emit(code.OpDefineLocal, 0)
+	}
+
+	body.depth = scope.depth
+	body.operands = scope.operands
+	scope.depth++
+	scope.loops = append(scope.loops, body)
+
+	if err := compiler.compileStatements(block.Statements); err != nil {
+		return err
+	}
+
+	scope.loops = scope.loops[:len(scope.loops)-1]
+	scope.depth--
+	compiler.emit(code.OpLeaveScope)
+
+	if len(compiler.symbolTable.Names()) > 256 {
+		return fmt.Errorf("Too many local variables: %d", len(compiler.symbolTable.Names()))
+	}
+
+	scope.scopes[index] = compiler.symbolTable.Names()
+	compiler.symbolTable = compiler.symbolTable.Outer
+	return nil
+}
+
+// compileLoopJump compiles break and continue. Both discard the operands put on the stack and leave the scopes
+// entered since the start of the loop body, and break also discards the iterator of a for loop.
+func (compiler *Compiler) compileLoopJump(keyword string, isBreak bool) error {
+	scope := compiler.currentScope()
+	if len(scope.loops) == 0 {
+		return fmt.Errorf("Cannot use %s outside of a loop", keyword)
+	}
+	body := scope.loops[len(scope.loops)-1]
+
+	for i := body.operands; i < scope.operands; i++ {
+		compiler.emit(code.OpPop)
+	}
+
+	for i := body.depth; i < scope.depth; i++ {
+		compiler.emit(code.OpLeaveScope)
+	}
+
+	if !isBreak {
+		compiler.emit(code.OpJump, body.start)
+		return nil
+	}
+
+	if body.iterator {
+		compiler.emit(code.OpPop)
+	}
+	body.breaks = append(body.breaks, compiler.emit(code.OpJump, 9999))
+	return nil
+}
+
+func (compiler *Compiler) patchBreaks(body *loop) {
+	end := len(compiler.currentInstructions())
+	for _, position := range body.breaks {
+		compiler.changeOperand(position, end)
+	}
+}
+
+// hoist allocates the slots of the variables declared by the statements before they are compiled, so functions can
+// use variables of their enclosing scopes which are declared after them, e.g. for mutual recursion. If blocks share the scope they
+// are in, so their declarations are hoisted as well, while function literals and loop bodies have their own scopes.
+func (compiler *Compiler) hoist(statements []ast.Statement) {
+	for _, statement := range statements {
+		switch statement := statement.(type) {
+		case *ast.LetStatement:
+			compiler.hoistExpression(statement.Value)
+			compiler.symbolTable.Hoist(statement.Name.Value, statement.IsConst())
+		case *ast.ExpressionStatement:
+			compiler.hoistExpression(statement.Expression)
+		case *ast.ReturnStatement:
+			compiler.hoistExpression(statement.ReturnValue)
+		case *ast.WhileStatement:
+			compiler.hoistExpression(statement.Condition)
+		case *ast.ForStatement:
+			compiler.hoistExpression(statement.Iterable)
+		}
+	}
+}
+
+func (compiler *Compiler) hoistExpression(expression ast.Expression) {
+	switch expression := expression.(type) {
+	case *ast.IfExpression:
+		compiler.hoistExpression(expression.Condition)
+		compiler.hoist(expression.Consequence.Statements)
+		if expression.Alternative != nil {
+			compiler.hoist(expression.Alternative.Statements)
+		}
+	case *ast.PrefixExpression:
+		compiler.hoistExpression(expression.Right)
+	case *ast.InfixExpression:
+		compiler.hoistExpression(expression.Left)
+		compiler.hoistExpression(expression.Right)
+	case *ast.CallExpression:
+		compiler.hoistExpression(expression.Function)
+		compiler.hoistExpressions(expression.Arguments)
+	case *ast.ArrayLiteral:
+		compiler.hoistExpressions(expression.Elements)
+	case *ast.HashLiteral:
+		for _, pair := range expression.Pairs {
+			compiler.hoistExpression(pair.Key)
+			compiler.hoistExpression(pair.Value)
+		}
+	case *ast.IndexExpression:
+		compiler.hoistExpression(expression.Left)
+		compiler.hoistExpression(expression.Index)
+	case *ast.SliceExpression:
+		compiler.hoistExpression(expression.Left)
+		compiler.hoistExpression(expression.Low)
+		compiler.hoistExpression(expression.High)
+	case *ast.AssignExpression:
+		compiler.hoistExpression(expression.Target)
+		compiler.hoistExpression(expression.Value)
+	}
+}
+
+func (compiler *Compiler) hoistExpressions(expressions []ast.Expression) {
+	for _, expression := range expressions {
+		compiler.hoistExpression(expression)
+	}
+}
+
+// resolve returns the symbol of the name. Names which are not declared anywhere become globals, so like in the
+// evaluator using them is only an error if the code using them runs before they are defined.
+func (compiler *Compiler) resolve(name string) Symbol {
+	symbol, ok := compiler.symbolTable.Resolve(name)
+	if !ok {
+		symbol = compiler.symbolTable.Global().Hoist(name, false)
+	}
+	return symbol
+}
+
+func (compiler *Compiler) loadSymbol(symbol Symbol) {
+	switch symbol.Scope {
+	case GlobalScope:
+		compiler.emit(code.OpGetGlobal, symbol.Index)
+	case LocalScope:
+		compiler.emit(code.OpGetLocal, symbol.Index)
+	case FreeScope:
+		compiler.emit(code.OpGetFree, symbol.Depth, symbol.Index)
+	}
+}
+
+func (compiler *Compiler) checkSymbol(symbol Symbol) {
+	switch symbol.Scope {
+	case GlobalScope:
+		compiler.emit(code.OpCheckGlobal, symbol.Index)
+	case LocalScope:
+		compiler.emit(code.OpCheckLocal, symbol.Index)
+	case FreeScope:
+		compiler.emit(code.OpCheckFree, symbol.Depth, symbol.Index)
+	}
+}
+
+func (compiler *Compiler) storeSymbol(symbol Symbol) {
+	switch symbol.Scope {
+	case GlobalScope:
+		compiler.emit(code.OpSetGlobal, symbol.Index)
+	case LocalScope:
+		compiler.emit(code.OpSetLocal, symbol.Index)
+	case FreeScope:
+		compiler.emit(code.OpSetFree, symbol.Depth, symbol.Index)
+	}
+}
+
+func (compiler *Compiler) addConstant(obj object.Object) int {
+	compiler.constants = append(compiler.constants, obj)
+	return len(compiler.constants) - 1
+}
+
+// emit appends the instruction to the current scope and returns its position
+func (compiler *Compiler) emit(op code.Opcode, operands ...int) int {
+	scope := compiler.currentScope()
+	position := len(scope.instructions)
+
+	compiler.checkOperands(op, operands...)
+	scope.instructions = append(scope.instructions, code.Make(op, operands...)...)
+	scope.lastInstruction = EmittedInstruction{Opcode: op, Position: position}
+
+	return position
+}
+
+func (compiler *Compiler) removeLastInstruction() {
+	scope := compiler.currentScope()
+	scope.instructions = scope.instructions[:scope.lastInstruction.Position]
+}
+
+func (compiler *Compiler) replaceLastInstruction(op code.Opcode) {
+	compiler.removeLastInstruction()
+	compiler.emit(op)
+}
+
+// changeOperand replaces the operand of the instruction at the position, used to patch jump targets
+func (compiler *Compiler) changeOperand(position int, operand int) {
+	scope := compiler.currentScope()
+	op := code.Opcode(scope.instructions[position])
+	compiler.checkOperands(op, operand)
+	copy(scope.instructions[position:], code.Make(op, operand))
+}
+
+// checkOperands records an error if an operand is too large for its width, e.g. the index of a constant in a program
+// with too many constants or the target of a jump in a function with too many instructions. Emitting instructions
+// does not fail, the error is returned once the whole program is compiled.
+func (compiler *Compiler) checkOperands(op code.Opcode, operands ...int) {
+	definition, err := code.Lookup(byte(op))
+	if err != nil || compiler.err != nil {
+		return
+	}
+
+	for i, operand := range operands {
+		limit := 1<<(8*uint(definition.OperandWidths[i])) - 1
+		if operand > limit {
+			compiler.err = fmt.Errorf("Operand %d of %s is too large, the limit is %d", operand, definition.Name, limit)
+			return
+		}
+	}
+}
+
+func (compiler *Compiler) currentScope() *CompilationScope {
+	return compiler.scopes[len(compiler.scopes)-1]
+}
+
+func (compiler *Compiler) currentInstructions() code.Instructions {
+	return compiler.currentScope().instructions
+}
+
+// enterScope starts compiling a function literal. The first of its scopes is the scope of the function itself.
+func (compiler *Compiler) enterScope() {
+	scope := &CompilationScope{scopes: [][]string{nil}, calls: map[int]*ast.CallExpression{}}
+	compiler.scopes = append(compiler.scopes, scope)
+	compiler.symbolTable = NewFunctionSymbolTable(compiler.symbolTable)
+}
+
+func (compiler *Compiler) leaveScope() *CompilationScope {
+	scope := compiler.currentScope()
+	compiler.scopes = compiler.scopes[:len(compiler.scopes)-1]
+	compiler.symbolTable = compiler.symbolTable.Outer
+	return scope
+}
+
+func endsWithExpression(block *ast.BlockStatement) bool {
+	if len(block.Statements) == 0 {
+		return false
+	}
+	_, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement)
+	return ok
+}
+
+func endsWithLoop(statements []ast.Statement) bool {
+	if len(statements) == 0 {
+		return false
+	}
+
+	switch statements[len(statements)-1].(type) {
+	case *ast.WhileStatement, *ast.ForStatement:
+		return true
+	default:
+		return false
+	}
+}
+
+func endsWithReturn(block *ast.BlockStatement) bool {
+	if len(block.Statements) == 0 {
+		return false
+	}
+	_, ok := block.Statements[len(block.Statements)-1].(*ast.ReturnStatement)
+	return ok
+}
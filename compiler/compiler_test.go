@@ -0,0 +1,772 @@
+package compiler
+
+import (
+	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/code"
+	"github.com/jpiechowka/micron-language-interpreter-go/lexer"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+	"github.com/jpiechowka/micron-language-interpreter-go/parser"
+	"strings"
+	"testing"
+)
+
+type compilerTestCase struct {
+	input                string
+	expectedConstants    []interface{} // int, float64, string or []code.Instructions for compiled functions
+	expectedInstructions []code.Instructions
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "1 + 2",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "1; 2",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "2 ** 3 % 4",
+			expectedConstants: []interface{}{2, 3, 4},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPow),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpMod),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "1 << 2 | ~3",
+			expectedConstants: []interface{}{1, 2, 3},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpShiftLeft),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpBitNot),
+				code.Make(code.OpBitOr),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "-1.5",
+			expectedConstants: []interface{}{1.5},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpMinus),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "0..3",
+			expectedConstants: []interface{}{0, 3},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpRange),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "!true",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpBang),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// The operands are not swapped, so they are evaluated and reported in source order
+			input:             "1 < 2 == false",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpLessThan),
+				code.Make(code.OpFalse),
+				code.Make(code.OpEqual),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "true && false",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),              // 0000
+				code.Make(code.OpJumpNotTruthy, 12), // 0001
+				code.Make(code.OpFalse),             // 0004
+				code.Make(code.OpJumpNotTruthy, 12), // 0005
+				code.Make(code.OpTrue),              // 0008
+				code.Make(code.OpJump, 13),          // 0009
+				code.Make(code.OpFalse),             // 0012
+				code.Make(code.OpPop),               // 0013
+			},
+		},
+		{
+			input:             "false || true",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpFalse),          // 0000
+				code.Make(code.OpJumpTruthy, 12), // 0001
+				code.Make(code.OpTrue),           // 0004
+				code.Make(code.OpJumpTruthy, 12), // 0005
+				code.Make(code.OpFalse),          // 0008
+				code.Make(code.OpJump, 13),       // 0009
+				code.Make(code.OpTrue),           // 0012
+				code.Make(code.OpPop),            // 0013
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "if (true) { 10 }; 3333;",
+			expectedConstants: []interface{}{10, 3333},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),              // 0000
+				code.Make(code.OpJumpNotTruthy, 10), // 0001
+				code.Make(code.OpConstant, 0),       // 0004
+				code.Make(code.OpJump, 11),          // 0007
+				code.Make(code.OpNull),              // 0010
+				code.Make(code.OpPop),               // 0011
+				code.Make(code.OpConstant, 1),       // 0012
+				code.Make(code.OpPop),               // 0015
+			},
+		},
+		{
+			input:             "if (true) { 10 } else { let x = 20; }",
+			expectedConstants: []interface{}{10, 20},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),              // 0000
+				code.Make(code.OpJumpNotTruthy, 10), // 0001
+				code.Make(code.OpConstant, 0),       // 0004
+				code.Make(code.OpJump, 17),          // 0007
+				code.Make(code.OpConstant, 1),       // 0010
+				code.Make(code.OpDefineGlobal, 0),   // 0013
+				code.Make(code.OpNull),              // 0016
+				code.Make(code.OpPop),               // 0017
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestLiterals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `"micron"`,
+			expectedConstants: []interface{}{"micron"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "[1, 2 + 3][0]",
+			expectedConstants: []interface{}{1, 2, 3, 0},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpAdd),
+				code.Make(code.OpArray, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpIndex),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `{"a": 1, true: 2}`,
+			expectedConstants: []interface{}{"a", 1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpTrue),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpHash, 4),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "[][1:]",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSlice, code.SliceLow),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "[][:]",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpSlice, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestGlobalBindings(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "let one = 1; let two = one; two;",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpDefineGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpDefineGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "const one = [1];",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpFreeze),
+				code.Make(code.OpDefineGlobal, 0),
+			},
+		},
+		{
+			// Undeclared names are left to the runtime
+			input:             "missing",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// g is hoisted, so f can use it before it is declared
+			input: "let f = fn() { g() }; let g = 1;",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetGlobal, 1),
+					code.Make(code.OpCall, 0),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0),
+				code.Make(code.OpDefineGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpDefineGlobal, 1),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "let x = 1; x += 2;",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpDefineGlobal, 0),
+				code.Make(code.OpCheckGlobal, 0), // Fails before the value is computed if x is undefined
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "let a = [1]; a[0] = 2;",
+			expectedConstants: []interface{}{1, 0, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpDefineGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpSetIndex),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "let a = [1]; a[0] *= 2;",
+			expectedConstants: []interface{}{1, 0, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpDefineGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpUpdateIndex, int(code.OpMul)),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "fn() { return 5 + 10 }",
+			expectedConstants: []interface{}{
+				5,
+				10,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn() { 5 + 10 }",
+			expectedConstants: []interface{}{
+				5,
+				10,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn() { }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpReturn),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "let f = fn(a, b) { let c = a; c }; f(1, 2);",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpDefineLocal, 2),
+					code.Make(code.OpGetLocal, 2),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+				2,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0),
+				code.Make(code.OpDefineGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpCall, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn(a) { fn(b) { a = b } }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpCheckFree, 1, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpSetFree, 1, 0),
+					code.Make(code.OpGetFree, 1, 0),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpClosure, 0),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestLoops(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "while (true) { break; continue; }",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),              // 0000
+				code.Make(code.OpJumpNotTruthy, 19), // 0001
+				code.Make(code.OpEnterScope, 0),     // 0004
+				code.Make(code.OpLeaveScope),        // 0007
+				code.Make(code.OpJump, 19),          // 0008
+				code.Make(code.OpLeaveScope),        // 0011
+				code.Make(code.OpJump, 0),           // 0012
+				code.Make(code.OpLeaveScope),        // 0015
+				code.Make(code.OpJump, 0),           // 0016
+				code.Make(code.OpNull),              // 0019, the loop is the result of the program
+				code.Make(code.OpPop),               // 0020
+			},
+		},
+		{
+			input:             "for (x in [1]) { x }",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),    // 0000
+				code.Make(code.OpArray, 1),       // 0003
+				code.Make(code.OpIterInit),       // 0006
+				code.Make(code.OpIterNext, 22),   // 0007
+				code.Make(code.OpEnterScope, 0),  // 0010
+				code.Make(code.OpDefineLocal, 0), // 0013
+				code.Make(code.OpGetLocal, 0),    // 0015
+				code.Make(code.OpPop),            // 0017
+				code.Make(code.OpLeaveScope),     // 0018
+				code.Make(code.OpJump, 7),        // 0019
+				code.Make(code.OpNull),           // 0022
+				code.Make(code.OpPop),            // 0023
+			},
+		},
+		{
+			// Breaking out of a for loop also discards its iterator
+			input:             "for (x in [1]) { break; }",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),    // 0000
+				code.Make(code.OpArray, 1),       // 0003
+				code.Make(code.OpIterInit),       // 0006
+				code.Make(code.OpIterNext, 24),   // 0007
+				code.Make(code.OpEnterScope, 0),  // 0010
+				code.Make(code.OpDefineLocal, 0), // 0013
+				code.Make(code.OpLeaveScope),     // 0015
+				code.Make(code.OpPop),            // 0016
+				code.Make(code.OpJump, 24),       // 0017
+				code.Make(code.OpLeaveScope),     // 0020
+				code.Make(code.OpJump, 7),        // 0021
+				code.Make(code.OpNull),           // 0024
+				code.Make(code.OpPop),            // 0025
+			},
+		},
+		{
+			input: "fn() { let a = 1; while (a) { let b = a; } }",
+			expectedConstants: []interface{}{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),       // 0000
+					code.Make(code.OpDefineLocal, 0),    // 0003
+					code.Make(code.OpGetLocal, 0),       // 0005
+					code.Make(code.OpJumpNotTruthy, 22), // 0007
+					code.Make(code.OpEnterScope, 1),     // 0010
+					code.Make(code.OpGetFree, 1, 0),     // 0013
+					code.Make(code.OpDefineLocal, 0),    // 0016
+					code.Make(code.OpLeaveScope),        // 0018
+					code.Make(code.OpJump, 5),           // 0019
+					code.Make(code.OpReturn),            // 0022
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestScopeNames(t *testing.T) {
+	input := `
+	let a = 1;
+	for (x in [a]) { let y = x; if (y) { let z = y; } }
+	let f = fn(p) { while (p) { let q = 1; } let r = 2; };
+	`
+
+	bytecode := compile(t, input)
+
+	expectedGlobals := []string{"a", "f"}
+	if fmt.Sprint(bytecode.Globals) != fmt.Sprint(expectedGlobals) {
+		t.Errorf("Wrong globals. Expected %v, got %v instead", expectedGlobals, bytecode.Globals)
+	}
+
+	expectedScopes := [][]string{{"x", "y", "z"}}
+	if fmt.Sprint(bytecode.Scopes) != fmt.Sprint(expectedScopes) {
+		t.Errorf("Wrong scopes. Expected %v, got %v instead", expectedScopes, bytecode.Scopes)
+	}
+
+	function, ok := bytecode.Constants[len(bytecode.Constants)-1].(*object.CompiledFunction)
+	if !ok {
+		t.Fatalf("Last constant is not CompiledFunction. Got %T instead", bytecode.Constants[len(bytecode.Constants)-1])
+	}
+
+	if function.NumParameters != 1 {
+		t.Errorf("Wrong number of parameters. Expected 1, got %d instead", function.NumParameters)
+	}
+
+	expectedScopes = [][]string{{"p", "r"}, {"q"}}
+	if fmt.Sprint(function.Scopes) != fmt.Sprint(expectedScopes) {
+		t.Errorf("Wrong function scopes. Expected %v, got %v instead", expectedScopes, function.Scopes)
+	}
+}
+
+func TestCompilerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let = 5;", "Cannot compile code containing syntax errors"},
+		{"1 + ;", "Cannot compile code containing syntax errors"},
+		// Not caught by the parser, as the constant is declared after the function
+		{"let f = fn() { x = 2 }; const x = 1;", "Cannot assign to constant x"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		err := New().Compile(program)
+		if err == nil {
+			t.Errorf("Compiling %q did not fail", tt.input)
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("Wrong error for %q. Expected %q, got %q instead", tt.input, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestOperandLimits(t *testing.T) {
+	globals := make([]string, 70000)
+	for i := range globals {
+		globals[i] = fmt.Sprintf("let x%d = true;", i)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{strings.Repeat("1; ", 70000), "Operand 65536 of OpConstant is too large, the limit is 65535"},
+		{strings.Join(globals, " "), "Operand 65536 of OpDefineGlobal is too large, the limit is 65535"},
+		{"[" + strings.Repeat("true, ", 69999) + "true]", "Operand 70000 of OpArray is too large, the limit is 65535"},
+		{"{" + strings.Repeat("true: 1.5, ", 39999) + "true: 1.5}", "Operand 80000 of OpHash is too large, the limit is 65535"},
+		{"if (true) { " + strings.Repeat("true; ", 40000) + "}", "Operand 80006 of OpJumpNotTruthy is too large, the limit is 65535"},
+		{
+			"for (x in []) { " + strings.Repeat("while (false) { ", 300) + "x" + strings.Repeat(" }", 301),
+			"Operand 300 of OpGetFree is too large, the limit is 255",
+		},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		err := New().Compile(program)
+		if err == nil {
+			t.Errorf("Compiling %.40q... did not fail", tt.input)
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("Wrong error for %.40q... Expected %q, got %q instead", tt.input, tt.expected, err.Error())
+		}
+	}
+}
+
+func runCompilerTests(t *testing.T, tests []compilerTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		bytecode := compile(t, tt.input)
+
+		if err := testInstructions(tt.expectedInstructions, bytecode.Instructions); err != nil {
+			t.Errorf("Wrong instructions for %q: %s", tt.input, err)
+		}
+
+		if err := testConstants(tt.expectedConstants, bytecode.Constants); err != nil {
+			t.Errorf("Wrong constants for %q: %s", tt.input, err)
+		}
+	}
+}
+
+func compile(t *testing.T, input string) *Bytecode {
+	t.Helper()
+
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.GetErrors()) != 0 {
+		t.Fatalf("Parser has errors for %q: %v", input, parser.GetErrors())
+	}
+
+	compiler := New()
+	if err := compiler.Compile(program); err != nil {
+		t.Fatalf("Compiler error for %q: %s", input, err)
+	}
+
+	return compiler.Bytecode()
+}
+
+func concatInstructions(instructions []code.Instructions) code.Instructions {
+	out := code.Instructions{}
+	for _, ins := range instructions {
+		out = append(out, ins...)
+	}
+	return out
+}
+
+func testInstructions(expected []code.Instructions, actual code.Instructions) error {
+	concatenated := concatInstructions(expected)
+
+	if len(actual) != len(concatenated) {
+		return fmt.Errorf("Wrong instructions length. Expected %v, got %v instead", concatenated, actual)
+	}
+
+	for i, ins := range concatenated {
+		if actual[i] != ins {
+			return fmt.Errorf("Wrong instruction at %d. Expected %v, got %v instead", i, concatenated, actual)
+		}
+	}
+
+	return nil
+}
+
+func testConstants(expected []interface{}, actual []object.Object) error {
+	if len(expected) != len(actual) {
+		return fmt.Errorf("Wrong number of constants. Expected %d, got %d instead", len(expected), len(actual))
+	}
+
+	for i, constant := range expected {
+		switch constant := constant.(type) {
+		case int:
+			integer, ok := actual[i].(*object.Integer)
+			if !ok || integer.Value != int64(constant) {
+				return fmt.Errorf("Constant %d is wrong. Expected %d, got %+v instead", i, constant, actual[i])
+			}
+		case float64:
+			float, ok := actual[i].(*object.Float)
+			if !ok || float.Value != constant {
+				return fmt.Errorf("Constant %d is wrong. Expected %g, got %+v instead", i, constant, actual[i])
+			}
+		case string:
+			str, ok := actual[i].(*object.String)
+			if !ok || str.Value != constant {
+				return fmt.Errorf("Constant %d is wrong. Expected %q, got %+v instead", i, constant, actual[i])
+			}
+		case []code.Instructions:
+			function, ok := actual[i].(*object.CompiledFunction)
+			if !ok {
+				return fmt.Errorf("Constant %d is not CompiledFunction. Got %T instead", i, actual[i])
+			}
+
+			if err := testInstructions(constant, function.Instructions); err != nil {
+				return fmt.Errorf("Constant %d has wrong instructions: %s", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestBytecodeString(t *testing.T) {
+	// The break leaves the loop body scope before the OpClosure, which must still resolve x in the loop body
+	input := `let f = fn(a) { for (x in [a]) { if (x) { break; } let g = fn(y) { x + y }; } }; f("s");`
+
+	expected := `globals: f
+instructions:
+  0000 OpClosure 1 (fn(a))
+    function with 1 parameter:
+      scopes:
+        0: a
+        1: x, g
+      instructions:
+        0000 OpGetLocal 0 (a)
+        0002 OpArray 1
+        0005 OpIterInit
+        0006 OpIterNext 39
+        0009 OpEnterScope 1
+        0012 OpDefineLocal 0 (x)
+        0014 OpGetLocal 0 (x)
+        0016 OpJumpNotTruthy 28
+        0019 OpLeaveScope
+        0020 OpPop
+        0021 OpJump 39
+        0024 OpNull
+        0025 OpJump 29
+        0028 OpNull
+        0029 OpPop
+        0030 OpClosure 0 (fn(y))
+          function with 1 parameter:
+            scopes:
+              0: y
+            instructions:
+              0000 OpGetFree 1 0 (x)
+              0003 OpGetLocal 0 (y)
+              0005 OpAdd
+              0006 OpReturnValue
+        0033 OpDefineLocal 1 (g)
+        0035 OpLeaveScope
+        0036 OpJump 6
+        0039 OpReturn
+  0003 OpDefineGlobal 0 (f)
+  0006 OpGetGlobal 0 (f)
+  0009 OpConstant 2 ("s")
+  0012 OpCall 1
+  0014 OpPop
+constants:
+  0: COMPILED_FUNCTION fn(y)
+  1: COMPILED_FUNCTION fn(a)
+  2: STRING "s"
+`
+
+	bytecode := compile(t, input)
+	if bytecode.String() != expected {
+		t.Errorf("Bytecode is wrongly disassembled. Expected:\n%s\nGot:\n%s", expected, bytecode.String())
+	}
+}
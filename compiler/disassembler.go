@@ -0,0 +1,200 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/code"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+	"strings"
+)
+
+// String disassembles the bytecode. Operands are annotated with what they refer to, e.g. the value of a constant or
+// the name of a variable. The instructions of compiled functions are listed under the OpClosure instruction creating
+// them, so nested functions are shown where they are defined. The constant pool follows the instructions.
+func (bytecode *Bytecode) String() string {
+	var out bytes.Buffer
+
+	disassembler := &disassembler{out: &out, constants: bytecode.Constants, globals: bytecode.Globals}
+
+	fmt.Fprintf(&out, "globals: %s\n", strings.Join(bytecode.Globals, ", "))
+	disassembler.writeFunction(bytecode.Instructions, bytecode.Scopes, nil, "")
+
+	out.WriteString("constants:\n")
+	for i, constant := range bytecode.Constants {
+		fmt.Fprintf(&out, "  %d: %s %s\n", i, constant.Type(), inspectConstant(constant))
+	}
+
+	return out.String()
+}
+
+func inspectConstant(constant object.Object) string {
+	switch constant := constant.(type) {
+	case *object.String:
+		return fmt.Sprintf("%q", constant.Value)
+	case *object.CompiledFunction:
+		return signature(constant)
+	}
+	return constant.Inspect()
+}
+
+// signature prints the parameters of the function, its body is listed by the disassembler
+func signature(function *object.CompiledFunction) string {
+	if function.Literal == nil {
+		return function.Inspect()
+	}
+
+	params := []string{}
+	for _, param := range function.Literal.Parameters {
+		params = append(params, param.String())
+	}
+
+	return fmt.Sprintf("fn(%s)", strings.Join(params, ", "))
+}
+
+// scopeChain holds the variable names of the scopes an instruction runs in, the innermost one first. It mirrors the
+// object.Scope chain of the virtual machine.
+type scopeChain struct {
+	names []string
+	outer *scopeChain
+}
+
+func (chain *scopeChain) up(depth int) *scopeChain {
+	for ; chain != nil && depth > 0; depth-- {
+		chain = chain.outer
+	}
+	return chain
+}
+
+func (chain *scopeChain) name(index int) string {
+	if chain == nil || index >= len(chain.names) {
+		return ""
+	}
+	return chain.names[index]
+}
+
+type disassembler struct {
+	out       *bytes.Buffer
+	constants []object.Object
+	globals   []string
+}
+
+// writeFunction lists the scopes and the instructions of the program or of a compiled function. The chain holds the
+// scopes the instructions start in.
+func (disassembler *disassembler) writeFunction(instructions code.Instructions, scopes [][]string, chain *scopeChain, indent string) {
+	if len(scopes) != 0 {
+		fmt.Fprintf(disassembler.out, "%sscopes:\n", indent)
+		for i, names := range scopes {
+			fmt.Fprintf(disassembler.out, "%s  %d: %s\n", indent, i, strings.Join(names, ", "))
+		}
+	}
+
+	chains := scopesAt(instructions, scopes, chain)
+
+	fmt.Fprintf(disassembler.out, "%sinstructions:\n", indent)
+	for ip := 0; ip < len(instructions); {
+		definition, err := code.Lookup(instructions[ip])
+		if err != nil {
+			fmt.Fprintf(disassembler.out, "%s  %04d ERROR: %s\n", indent, ip, err)
+			return
+		}
+
+		operands, read := code.ReadOperands(definition, instructions[ip+1:])
+		op := code.Opcode(instructions[ip])
+
+		line := code.FormatInstruction(op, definition, operands)
+		if annotation := disassembler.annotate(op, operands, chains[ip]); annotation != "" {
+			line += " (" + annotation + ")"
+		}
+		fmt.Fprintf(disassembler.out, "%s  %04d %s\n", indent, ip, line)
+
+		if op == code.OpClosure && operands[0] < len(disassembler.constants) {
+			if function, ok := disassembler.constants[operands[0]].(*object.CompiledFunction); ok {
+				fmt.Fprintf(disassembler.out, "%s    function with %s:\n", indent, parameters(function.NumParameters))
+				var functionChain *scopeChain
+				if len(function.Scopes) != 0 {
+					functionChain = &scopeChain{names: function.Scopes[0], outer: chains[ip]}
+				}
+				disassembler.writeFunction(function.Instructions, function.Scopes, functionChain, indent+"      ")
+			}
+		}
+
+		ip += 1 + read
+	}
+}
+
+// annotate describes what the operands of the instruction refer to, or returns "" if there is nothing to add
+func (disassembler *disassembler) annotate(op code.Opcode, operands []int, chain *scopeChain) string {
+	switch op {
+	case code.OpConstant, code.OpClosure:
+		if operands[0] < len(disassembler.constants) {
+			return inspectConstant(disassembler.constants[operands[0]])
+		}
+	case code.OpGetGlobal, code.OpSetGlobal, code.OpDefineGlobal, code.OpCheckGlobal:
+		if operands[0] < len(disassembler.globals) {
+			return disassembler.globals[operands[0]]
+		}
+	case code.OpGetLocal, code.OpSetLocal, code.OpDefineLocal, code.OpCheckLocal:
+		return chain.name(operands[0])
+	case code.OpGetFree, code.OpSetFree, code.OpCheckFree:
+		return chain.up(operands[0]).name(operands[1])
+	}
+
+	return ""
+}
+
+// scopesAt finds the scopes every reachable instruction runs in, starting with the given chain. The jumps have to be
+// followed, because break and continue statements leave the scopes of the loop body before jumping, so reading the
+// instructions in order would leave the scopes too early.
+func scopesAt(instructions code.Instructions, scopes [][]string, chain *scopeChain) map[int]*scopeChain {
+	chains := map[int]*scopeChain{0: chain}
+	pending := []int{0}
+
+	for len(pending) > 0 {
+		ip := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if ip >= len(instructions) {
+			continue
+		}
+
+		definition, err := code.Lookup(instructions[ip])
+		if err != nil {
+			continue
+		}
+
+		operands, read := code.ReadOperands(definition, instructions[ip+1:])
+		current := chains[ip]
+		next := []int{ip + 1 + read}
+
+		switch code.Opcode(instructions[ip]) {
+		case code.OpEnterScope:
+			if operands[0] < len(scopes) {
+				current = &scopeChain{names: scopes[operands[0]], outer: current}
+			}
+		case code.OpLeaveScope:
+			current = current.up(1)
+		case code.OpJump:
+			next = []int{operands[0]}
+		case code.OpJumpNotTruthy, code.OpJumpTruthy, code.OpIterNext:
+			next = append(next, operands[0])
+		case code.OpReturnValue, code.OpReturn:
+			next = nil
+		}
+
+		for _, target := range next {
+			if _, ok := chains[target]; !ok {
+				chains[target] = current
+				pending = append(pending, target)
+			}
+		}
+	}
+
+	return chains
+}
+
+func parameters(count int) string {
+	if count == 1 {
+		return "1 parameter"
+	}
+	return fmt.Sprintf("%d parameters", count)
+}
@@ -0,0 +1,132 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope SymbolScope = "GLOBAL"
+	LocalScope  SymbolScope = "LOCAL" // variable of the innermost scope
+	FreeScope   SymbolScope = "FREE"  // variable of an enclosing function or loop body, captured by reference
+)
+
+type Symbol struct {
+	Name     string
+	Scope    SymbolScope
+	Index    int
+	Depth    int // number of scopes between the use of a free symbol and the scope defining it
+	Constant bool
+}
+
+// SymbolTable assigns slots to the variables of a scope. The outermost table holds the globals, every other table
+// is a scope created at runtime, i.e. a function call or an iteration of a loop body.
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store    map[string]Symbol // variables declared so far
+	hoisted  map[string]Symbol // all variables of the scope, including the ones declared further on
+	names    []string          // by slot
+	function bool              // whether the scope is created by a function call
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{store: make(map[string]Symbol), hoisted: make(map[string]Symbol)}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	symbolTable := NewSymbolTable()
+	symbolTable.Outer = outer
+	return symbolTable
+}
+
+// NewFunctionSymbolTable returns the table of the scope of a function literal
+func NewFunctionSymbolTable(outer *SymbolTable) *SymbolTable {
+	symbolTable := NewEnclosedSymbolTable(outer)
+	symbolTable.function = true
+	return symbolTable
+}
+
+// Hoist returns the slot of the name in this scope, allocating it first if the name is new, without declaring the
+// variable yet. Until it is declared, only the functions defined in the scope can use the variable, as they may be
+// called after the declaration. The hoisted symbol is constant if any declaration of the name is.
+func (symbolTable *SymbolTable) Hoist(name string, constant bool) Symbol {
+	symbol, ok := symbolTable.hoisted[name]
+	if !ok {
+		return symbolTable.define(name, constant)
+	}
+
+	if constant && !symbol.Constant {
+		symbol.Constant = true
+		symbolTable.hoisted[name] = symbol
+	}
+
+	return symbol
+}
+
+// Define declares the name in this scope, from where on it shadows the variables of the enclosing scopes.
+// Redefining a name keeps its slot, like a let statement overwrites the binding of the scope in the evaluator, but
+// the symbol takes the constness of the latest declaration.
+func (symbolTable *SymbolTable) Define(name string, constant bool) Symbol {
+	symbol := symbolTable.Hoist(name, constant)
+	symbol.Constant = constant
+	symbolTable.store[name] = symbol
+	return symbol
+}
+
+// DefineParameter always allocates a new slot, a repeated parameter name shadows the earlier ones
+func (symbolTable *SymbolTable) DefineParameter(name string) Symbol {
+	symbol := symbolTable.define(name, false)
+	symbolTable.store[name] = symbol
+	return symbol
+}
+
+func (symbolTable *SymbolTable) define(name string, constant bool) Symbol {
+	symbol := Symbol{Name: name, Scope: LocalScope, Index: len(symbolTable.names), Constant: constant}
+	if symbolTable.Outer == nil {
+		symbol.Scope = GlobalScope
+	}
+
+	symbolTable.hoisted[name] = symbol
+	symbolTable.names = append(symbolTable.names, name)
+	return symbol
+}
+
+// Resolve looks the name up in this scope and then in the enclosing ones. A variable which is not declared yet is
+// skipped, unless it is used by a function defined in its scope. This way the value of `let x = x + 1` is computed
+// from the x of the enclosing scopes, while functions can still refer to each other regardless of their order.
+func (symbolTable *SymbolTable) Resolve(name string) (Symbol, bool) {
+	depth := 0
+	inFunction := false // whether the use is in a function defined in the scope of the current table
+	for table := symbolTable; table != nil; table = table.Outer {
+		symbol, ok := table.store[name]
+		if !ok && inFunction {
+			symbol, ok = table.hoisted[name]
+		}
+
+		if !ok {
+			depth++
+			inFunction = inFunction || table.function
+			continue
+		}
+
+		if symbol.Scope == LocalScope && depth > 0 {
+			symbol.Scope = FreeScope
+			symbol.Depth = depth
+		}
+		return symbol, true
+	}
+
+	return Symbol{}, false
+}
+
+// Global returns the outermost table
+func (symbolTable *SymbolTable) Global() *SymbolTable {
+	table := symbolTable
+	for table.Outer != nil {
+		table = table.Outer
+	}
+	return table
+}
+
+// Names returns the names of the variables in this scope by slot
+func (symbolTable *SymbolTable) Names() []string {
+	return symbolTable.names
+}
@@ -0,0 +1,127 @@
+package compiler
+
+import "testing"
+
+func TestDefine(t *testing.T) {
+	global := NewSymbolTable()
+	local := NewEnclosedSymbolTable(global)
+
+	tests := []struct {
+		table    *SymbolTable
+		name     string
+		constant bool
+		expected Symbol
+	}{
+		{global, "a", false, Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{global, "b", true, Symbol{Name: "b", Scope: GlobalScope, Index: 1, Constant: true}},
+		{global, "a", true, Symbol{Name: "a", Scope: GlobalScope, Index: 0, Constant: true}}, // Keeps its slot
+		{global, "a", false, Symbol{Name: "a", Scope: GlobalScope, Index: 0}},                // Latest declaration wins
+		{local, "a", false, Symbol{Name: "a", Scope: LocalScope, Index: 0}},
+		{local, "c", false, Symbol{Name: "c", Scope: LocalScope, Index: 1}},
+	}
+
+	for _, tt := range tests {
+		symbol := tt.table.Define(tt.name, tt.constant)
+		if symbol != tt.expected {
+			t.Errorf("Define(%q) is wrong. Expected %+v, got %+v instead", tt.name, tt.expected, symbol)
+		}
+	}
+
+	if len(global.Names()) != 2 || len(local.Names()) != 2 {
+		t.Errorf("Wrong number of slots. Got %v and %v instead", global.Names(), local.Names())
+	}
+}
+
+func TestDefineParameter(t *testing.T) {
+	table := NewEnclosedSymbolTable(NewSymbolTable())
+	table.DefineParameter("x")
+	table.DefineParameter("x")
+
+	symbol, ok := table.Resolve("x")
+	if !ok || symbol.Index != 1 {
+		t.Errorf("Repeated parameter does not shadow the first one. Got %+v instead", symbol)
+	}
+
+	if len(table.Names()) != 2 {
+		t.Errorf("Wrong number of slots. Expected 2, got %v instead", table.Names())
+	}
+}
+
+func TestResolve(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a", false)
+
+	function := NewEnclosedSymbolTable(global)
+	function.Define("b", false)
+
+	loop := NewEnclosedSymbolTable(function)
+	loop.Define("c", false)
+
+	inner := NewEnclosedSymbolTable(loop)
+	inner.Define("d", true)
+	inner.Define("b", false) // Shadows b of the function
+
+	tests := []struct {
+		table    *SymbolTable
+		name     string
+		expected Symbol
+	}{
+		{inner, "a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{inner, "b", Symbol{Name: "b", Scope: LocalScope, Index: 1}},
+		{inner, "c", Symbol{Name: "c", Scope: FreeScope, Index: 0, Depth: 1}},
+		{inner, "d", Symbol{Name: "d", Scope: LocalScope, Index: 0, Constant: true}},
+		{loop, "b", Symbol{Name: "b", Scope: FreeScope, Index: 0, Depth: 1}},
+		{loop, "a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+	}
+
+	for _, tt := range tests {
+		symbol, ok := tt.table.Resolve(tt.name)
+		if !ok {
+			t.Errorf("Name %q is not resolvable", tt.name)
+			continue
+		}
+
+		if symbol != tt.expected {
+			t.Errorf("Resolve(%q) is wrong. Expected %+v, got %+v instead", tt.name, tt.expected, symbol)
+		}
+	}
+
+	if _, ok := inner.Resolve("missing"); ok {
+		t.Errorf("Undefined name was resolved")
+	}
+
+	if inner.Global() != global {
+		t.Errorf("Global() did not return the outermost table")
+	}
+}
+
+func TestResolveHoisted(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("x", false)
+
+	function := NewFunctionSymbolTable(global)
+	function.Hoist("x", false)
+	function.Hoist("f", false)
+
+	inner := NewFunctionSymbolTable(function)
+
+	// Not declared yet, so the code of the function itself uses the global x
+	if symbol, ok := function.Resolve("x"); !ok || symbol.Scope != GlobalScope {
+		t.Errorf("Hoisted variable shadows before its declaration. Got %+v instead", symbol)
+	}
+	if _, ok := function.Resolve("f"); ok {
+		t.Errorf("Hoisted variable was resolved before its declaration")
+	}
+
+	// Functions defined in the scope can be called after the declaration, so they use the hoisted variables
+	expected := Symbol{Name: "x", Scope: FreeScope, Index: 0, Depth: 1}
+	if symbol, ok := inner.Resolve("x"); !ok || symbol != expected {
+		t.Errorf("Resolve(%q) is wrong. Expected %+v, got %+v instead", "x", expected, symbol)
+	}
+
+	function.Define("x", false)
+	expected = Symbol{Name: "x", Scope: LocalScope, Index: 0}
+	if symbol, ok := function.Resolve("x"); !ok || symbol != expected {
+		t.Errorf("Resolve(%q) is wrong. Expected %+v, got %+v instead", "x", expected, symbol)
+	}
+}
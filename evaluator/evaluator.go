@@ -0,0 +1,979 @@
+package evaluator
+
+import (
+	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/ast"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+	"math"
+	"strings"
+)
+
+// There is only ever a need for one instance of null, true and false, so they are shared
+// instead of allocating a new object every time one of them is produced.
+var (
+	NULL     = &object.Null{}
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
+)
+
+func Eval(node ast.AstNode, env *object.Environment) object.Object {
+	switch node := node.(type) {
+
+	// Statements
+	case *ast.Program:
+		return evalProgram(node, env)
+	case *ast.ExpressionStatement:
+		return Eval(node.Expression, env)
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env, false)
+	case *ast.LetStatement:
+		value := Eval(node.Value, env)
+		if stopsEvaluation(value) {
+			return value
+		}
+
+		if env.IsLocalConst(node.Name.Value) {
+			return newError("Cannot redeclare constant %s", node.Name.Value)
+		}
+
+		if node.IsConst() {
+			env.SetConst(node.Name.Value, value)
+		} else {
+			env.Set(node.Name.Value, value)
+		}
+	case *ast.BadStatement:
+		return newError("Cannot evaluate code containing syntax errors")
+	case *ast.ReturnStatement:
+		var value object.Object
+		if call, ok := node.ReturnValue.(*ast.CallExpression); ok {
+			// Returning the result of a call is a tail call, the call is made once the function has returned
+			value = evalCall(call, env)
+		} else {
+			value = Eval(node.ReturnValue, env)
+		}
+		if stopsEvaluation(value) {
+			return value
+		}
+		return &object.ReturnValue{Value: value}
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
+
+	// Expressions
+	case *ast.BadExpression:
+		return newError("Cannot evaluate code containing syntax errors")
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.PrefixExpression:
+		right := Eval(node.Right, env)
+		if stopsEvaluation(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
+
+		left := Eval(node.Left, env)
+		if stopsEvaluation(left) {
+			return left
+		}
+		right := Eval(node.Right, env)
+		if stopsEvaluation(right) {
+			return right
+		}
+		return evalInfixExpression(node.Operator, left, right)
+	case *ast.IfExpression:
+		return evalIfExpression(node, env, false)
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.FunctionLiteral:
+		return &object.Function{Parameters: node.Parameters, Body: node.Body, Env: env}
+	case *ast.CallExpression:
+		return runTailCalls(evalCall(node, env))
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && stopsEvaluation(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if stopsEvaluation(left) {
+			return left
+		}
+
+		index := Eval(node.Index, env)
+		if stopsEvaluation(index) {
+			return index
+		}
+
+		return evalIndexExpression(left, index)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	}
+
+	return nil
+}
+
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range program.Statements {
+		result = Eval(statement, env)
+
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return runTailCalls(result.Value)
+		case *object.Error:
+			return result
+		}
+	}
+
+	return result
+}
+
+// evalBlockStatement differs from evalProgram in that it does not unwrap return values. They have to
+// bubble up through all nested blocks until they reach the function call or program boundary.
+// If the block is in tail position, its last statement is evaluated as a tail, see evalTailStatement.
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment, tail bool) object.Object {
+	var result object.Object
+
+	for i, statement := range block.Statements {
+		if tail && i == len(block.Statements)-1 {
+			result = evalTailStatement(statement, env)
+		} else {
+			result = Eval(statement, env)
+		}
+
+		if stopsEvaluation(result) {
+			return result
+		}
+	}
+
+	// Blocks are used as values (e.g. the result of an if expression), so they must never produce nil
+	if result == nil {
+		return NULL
+	}
+
+	return result
+}
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalBangOperatorExpression(right)
+	case "-":
+		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalTildePrefixOperatorExpression(right)
+	default:
+		return newError("Unknown operator: %s%s", operator, right.Type())
+	}
+}
+
+func evalBangOperatorExpression(right object.Object) object.Object {
+	if isTruthy(right) {
+		return FALSE
+	}
+	return TRUE
+}
+
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -right.Value}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError("Unknown operator: -%s", right.Type())
+	}
+}
+
+func evalTildePrefixOperatorExpression(right object.Object) object.Object {
+	integer, ok := right.(*object.Integer)
+	if !ok {
+		return newError("Unknown operator: ~%s", right.Type())
+	}
+
+	return &object.Integer{Value: ^integer.Value}
+}
+
+func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case left.Type() != right.Type():
+		return newError("Type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	case operator == "==":
+		// Booleans and null are singletons, so pointer comparison is enough here
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	default:
+		return newError("Unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalLogicalExpression evaluates && and || with short-circuiting, the right operand is only evaluated if the left
+// one does not decide the result already. The result is always a boolean based on the truthiness of the operands.
+func evalLogicalExpression(expression *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(expression.Left, env)
+	if stopsEvaluation(left) {
+		return left
+	}
+
+	if expression.Operator == "&&" && !isTruthy(left) {
+		return FALSE
+	}
+
+	if expression.Operator == "||" && isTruthy(left) {
+		return TRUE
+	}
+
+	right := Eval(expression.Right, env)
+	if stopsEvaluation(right) {
+		return right
+	}
+
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+func evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftValue := left.(*object.Integer).Value
+	rightValue := right.(*object.Integer).Value
+
+	switch operator {
+	case "+":
+		return &object.Integer{Value: leftValue + rightValue}
+	case "-":
+		return &object.Integer{Value: leftValue - rightValue}
+	case "*":
+		return &object.Integer{Value: leftValue * rightValue}
+	case "/":
+		if rightValue == 0 {
+			return newError("Division by zero: %d / %d", leftValue, rightValue)
+		}
+		return &object.Integer{Value: leftValue / rightValue}
+	case "%":
+		if rightValue == 0 {
+			return newError("Modulo by zero: %d %% %d", leftValue, rightValue)
+		}
+		return &object.Integer{Value: leftValue % rightValue}
+	case "**":
+		if rightValue < 0 {
+			// A negative exponent produces a fraction, so the result can only be represented as a float
+			return &object.Float{Value: math.Pow(float64(leftValue), float64(rightValue))}
+		}
+		return &object.Integer{Value: integerPower(leftValue, rightValue)}
+	case "&":
+		return &object.Integer{Value: leftValue & rightValue}
+	case "|":
+		return &object.Integer{Value: leftValue | rightValue}
+	case "^":
+		return &object.Integer{Value: leftValue ^ rightValue}
+	case "<<", ">>":
+		return evalShiftExpression(operator, leftValue, rightValue)
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
+	case "==":
+		return nativeBoolToBooleanObject(leftValue == rightValue)
+	case "!=":
+		return nativeBoolToBooleanObject(leftValue != rightValue)
+	case "..":
+		return &object.Range{Start: leftValue, End: rightValue}
+	default:
+		return newError("Unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalFloatInfixExpression handles arithmetic where at least one of the operands is a float. Integers are converted
+// to floats first, so 1 + 0.5 is 1.5 and 1 == 1.0 is true.
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftValue := toFloat(left)
+	rightValue := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftValue + rightValue}
+	case "-":
+		return &object.Float{Value: leftValue - rightValue}
+	case "*":
+		return &object.Float{Value: leftValue * rightValue}
+	case "/":
+		if rightValue == 0 {
+			return newError("Division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: leftValue / rightValue}
+	case "%":
+		if rightValue == 0 {
+			return newError("Modulo by zero: %s %% %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: math.Mod(leftValue, rightValue)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftValue, rightValue)}
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
+	case "==":
+		return nativeBoolToBooleanObject(leftValue == rightValue)
+	case "!=":
+		return nativeBoolToBooleanObject(leftValue != rightValue)
+	default:
+		return newError("Unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// integerPower raises base to a non-negative exponent by repeated squaring. Like the other integer operators it
+// wraps around on overflow.
+func integerPower(base int64, exponent int64) int64 {
+	result := int64(1)
+
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			result *= base
+		}
+		base *= base
+		exponent >>= 1
+	}
+
+	return result
+}
+
+// evalShiftExpression shifts left or right by the given number of bits. The right shift is arithmetic, so the sign
+// of the value is kept. Shifting by a negative count or by more bits than an integer has is reported as an error.
+func evalShiftExpression(operator string, value int64, count int64) object.Object {
+	if count < 0 {
+		return newError("Negative shift count: %d %s %d", value, operator, count)
+	}
+
+	if count >= 64 {
+		return newError("Shift count too large: %d %s %d", value, operator, count)
+	}
+
+	if operator == "<<" {
+		return &object.Integer{Value: value << uint64(count)}
+	}
+
+	return &object.Integer{Value: value >> uint64(count)}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
+// evalStringInfixExpression supports concatenation and comparison. Strings are compared byte-wise, which for
+// UTF-8 encoded text is the same as comparing them by code points.
+func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftValue := left.(*object.String).Value
+	rightValue := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftValue + rightValue}
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
+	case "==":
+		return nativeBoolToBooleanObject(leftValue == rightValue)
+	case "!=":
+		return nativeBoolToBooleanObject(leftValue != rightValue)
+	default:
+		return newError("Unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalIfExpression(ifExpression *ast.IfExpression, env *object.Environment, tail bool) object.Object {
+	condition := Eval(ifExpression.Condition, env)
+	if stopsEvaluation(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return evalBlockStatement(ifExpression.Consequence, env, tail)
+	} else if ifExpression.Alternative != nil {
+		return evalBlockStatement(ifExpression.Alternative, env, tail)
+	} else {
+		return NULL
+	}
+}
+
+func evalIndexExpression(left object.Object, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left.(*object.Array), index.(*object.Integer).Value)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left.(*object.Hash), index)
+	default:
+		return newError("Index operator not supported: %s[%s]", left.Type(), index.Type())
+	}
+}
+
+// evalArrayIndexExpression returns the element at the index. Negative indices count from the end of the array,
+// so -1 is the last element.
+func evalArrayIndexExpression(array *object.Array, index int64) object.Object {
+	length := int64(len(array.Elements))
+
+	position := index
+	if position < 0 {
+		position += length
+	}
+
+	if position < 0 || position >= length {
+		return newError("Index out of range: %d with length %d", index, length)
+	}
+
+	return array.Elements[position]
+}
+
+// evalHashIndexExpression returns the value stored under the key, or null if the hash does not contain the key
+func evalHashIndexExpression(hash *object.Hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("Unusable as hash key: %s", index.Type())
+	}
+
+	pair, ok := hash.Get(key)
+	if !ok {
+		return NULL
+	}
+
+	return pair.Value
+}
+
+// evalSliceExpression evaluates the operands of a slice expression, see evalSlice. Omitted bounds stay nil.
+func evalSliceExpression(slice *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(slice.Left, env)
+	if stopsEvaluation(left) {
+		return left
+	}
+
+	if left.Type() != object.ARRAY_OBJ {
+		return newError("Slice operator not supported: %s", left.Type())
+	}
+
+	var low, high object.Object
+	if slice.Low != nil {
+		if low = Eval(slice.Low, env); stopsEvaluation(low) {
+			return low
+		}
+	}
+	if slice.High != nil {
+		if high = Eval(slice.High, env); stopsEvaluation(high) {
+			return high
+		}
+	}
+
+	return evalSlice(left, low, high)
+}
+
+// evalSlice returns a new array with the elements from the low bound up to, but not including, the high bound.
+// Omitted (nil) bounds default to the start and the end of the array and negative bounds count from the end.
+func evalSlice(left object.Object, low object.Object, high object.Object) object.Object {
+	array, ok := left.(*object.Array)
+	if !ok {
+		return newError("Slice operator not supported: %s", left.Type())
+	}
+
+	length := int64(len(array.Elements))
+
+	rawLow, err := sliceBound(low, 0)
+	if err != nil {
+		return err
+	}
+
+	rawHigh, err := sliceBound(high, length)
+	if err != nil {
+		return err
+	}
+
+	lowPosition, highPosition := rawLow, rawHigh
+	if lowPosition < 0 {
+		lowPosition += length
+	}
+	if highPosition < 0 {
+		highPosition += length
+	}
+
+	if lowPosition < 0 || highPosition > length || lowPosition > highPosition {
+		return newError("Slice bounds out of range: [%d:%d] with length %d", rawLow, rawHigh, length)
+	}
+
+	elements := make([]object.Object, highPosition-lowPosition)
+	copy(elements, array.Elements[lowPosition:highPosition])
+
+	return &object.Array{Elements: elements}
+}
+
+// sliceBound checks a bound of a slice, using the fallback if the bound was omitted
+func sliceBound(bound object.Object, fallback int64) (int64, *object.Error) {
+	if bound == nil {
+		return fallback, nil
+	}
+
+	integer, ok := bound.(*object.Integer)
+	if !ok {
+		return 0, newError("Slice bound must be an integer, got %s", bound.Type())
+	}
+
+	return integer.Value, nil
+}
+
+// evalHashLiteral evaluates the pairs in source order. If a key appears more than once, the last value wins, but
+// the key keeps the position of its first appearance.
+func evalHashLiteral(hashLiteral *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range hashLiteral.Pairs {
+		key := Eval(pair.Key, env)
+		if stopsEvaluation(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("Unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(pair.Value, env)
+		if stopsEvaluation(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return hash
+}
+
+// evalAssignExpression assigns to a binding or to an element of an array or hash and produces the assigned value.
+// A compound assignment like x += 1 applies the operator to the current value first.
+func evalAssignExpression(assignExpression *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := assignExpression.Target.(type) {
+	case *ast.Identifier:
+		return evalIdentifierAssignment(target, assignExpression, env)
+	case *ast.IndexExpression:
+		return evalIndexAssignment(target, assignExpression, env)
+	default:
+		return newError("Cannot assign to %s", target.String())
+	}
+}
+
+func evalIdentifierAssignment(identifier *ast.Identifier, assignExpression *ast.AssignExpression, env *object.Environment) object.Object {
+	current, ok := env.Get(identifier.Value)
+	if !ok {
+		return newError("Assignment to undeclared identifier: %s", identifier.Value)
+	}
+
+	if env.IsConst(identifier.Value) {
+		return newError("Cannot assign to constant %s", identifier.Value)
+	}
+
+	value := evalAssignedValue(assignExpression, current, env)
+	if stopsEvaluation(value) {
+		return value
+	}
+
+	env.Assign(identifier.Value, value)
+
+	return value
+}
+
+func evalIndexAssignment(indexExpression *ast.IndexExpression, assignExpression *ast.AssignExpression, env *object.Environment) object.Object {
+	left := Eval(indexExpression.Left, env)
+	if stopsEvaluation(left) {
+		return left
+	}
+
+	index := Eval(indexExpression.Index, env)
+	if stopsEvaluation(index) {
+		return index
+	}
+
+	compound := assignExpression.Operator != "="
+
+	return assignIndex(left, index, compound, func(current object.Object) object.Object {
+		return evalAssignedValue(assignExpression, current, env)
+	})
+}
+
+// assignIndex stores a value in an element of an array or under a key of a hash and returns the value. The value is
+// only computed by assignedValue once the target has been checked, and it gets the current value of the target for
+// compound assignments.
+func assignIndex(left object.Object, index object.Object, compound bool, assignedValue func(current object.Object) object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		if left.Frozen {
+			return newError("Cannot modify frozen %s", left.Type())
+		}
+
+		integer, ok := index.(*object.Integer)
+		if !ok {
+			return newError("Index operator not supported: %s[%s]", left.Type(), index.Type())
+		}
+
+		current := evalArrayIndexExpression(left, integer.Value)
+		if isError(current) {
+			return current
+		}
+
+		value := assignedValue(current)
+		if stopsEvaluation(value) {
+			return value
+		}
+
+		position := integer.Value
+		if position < 0 {
+			position += int64(len(left.Elements))
+		}
+		left.Elements[position] = value
+
+		return value
+	case *object.Hash:
+		if left.Frozen {
+			return newError("Cannot modify frozen %s", left.Type())
+		}
+
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("Unusable as hash key: %s", index.Type())
+		}
+
+		// A compound assignment needs an existing value, a plain one can add a new key
+		var current object.Object
+		if compound {
+			pair, ok := left.Get(key)
+			if !ok {
+				return newError("Key not found: %s", index.Inspect())
+			}
+			current = pair.Value
+		}
+
+		value := assignedValue(current)
+		if stopsEvaluation(value) {
+			return value
+		}
+
+		left.Set(key, value)
+
+		return value
+	default:
+		return newError("Index assignment not supported: %s", left.Type())
+	}
+}
+
+// evalAssignedValue evaluates the right side of the assignment. For compound assignments it is combined with the
+// current value of the target using the operator without the trailing '=', e.g. + for +=.
+func evalAssignedValue(assignExpression *ast.AssignExpression, current object.Object, env *object.Environment) object.Object {
+	value := Eval(assignExpression.Value, env)
+	if stopsEvaluation(value) {
+		return value
+	}
+
+	if assignExpression.Operator == "=" {
+		return value
+	}
+
+	operator := strings.TrimSuffix(assignExpression.Operator, "=")
+
+	return evalInfixExpression(operator, current, value)
+}
+
+// evalWhileStatement runs the loop iteratively, so the number of iterations is not limited by the Go stack. Each
+// iteration gets its own scope, so bindings created in the body do not outlive it.
+func evalWhileStatement(whileStatement *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(whileStatement.Condition, env)
+		if stopsEvaluation(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		if result, done := evalLoopBody(whileStatement.Body, object.NewEnclosedEnvironment(env)); done {
+			return result
+		}
+	}
+}
+
+// evalForStatement iterates over the elements of an array, the keys of a hash in insertion order or the integers of
+// a range. The loop variable is bound in a new scope for every iteration.
+func evalForStatement(forStatement *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(forStatement.Iterable, env)
+	if stopsEvaluation(iterable) {
+		return iterable
+	}
+
+	iterate := func(value object.Object) (object.Object, bool) {
+		iterationEnv := object.NewEnclosedEnvironment(env)
+		iterationEnv.Set(forStatement.Variable.Value, value)
+		return evalLoopBody(forStatement.Body, iterationEnv)
+	}
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		for _, element := range iterable.Elements {
+			if result, done := iterate(element); done {
+				return result
+			}
+		}
+	case *object.Hash:
+		for _, pair := range iterable.Pairs() {
+			if result, done := iterate(pair.Key); done {
+				return result
+			}
+		}
+	case *object.Range:
+		for i := iterable.Start; i < iterable.End; i++ {
+			if result, done := iterate(&object.Integer{Value: i}); done {
+				return result
+			}
+		}
+	default:
+		return newError("Cannot iterate over %s", iterable.Type())
+	}
+
+	return NULL
+}
+
+// evalLoopBody runs a single iteration of a loop. It reports whether the loop is done, together with the result
+// the loop should produce in that case: null after a break, or the return value or error leaving the loop.
+func evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	result := Eval(body, env)
+
+	switch result.Type() {
+	case object.BREAK_OBJ:
+		return NULL, true
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+		return result, true
+	default:
+		return nil, false
+	}
+}
+
+func evalIdentifier(identifier *ast.Identifier, env *object.Environment) object.Object {
+	if value, ok := env.Get(identifier.Value); ok {
+		return value
+	}
+
+	return newError("Identifier not found: %s", identifier.Value)
+}
+
+// evalExpressions evaluates the expressions from left to right. If any of them fails, only the error is returned.
+func evalExpressions(expressions []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+
+	for _, expression := range expressions {
+		evaluated := Eval(expression, env)
+		if stopsEvaluation(evaluated) {
+			return []object.Object{evaluated}
+		}
+
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+// tailCall is a call which has been evaluated up to the point of entering the function. It is produced instead of
+// the result of a call in tail position, i.e. when the caller has nothing left to do but to return the result.
+// Running it only after the caller returned keeps the Go stack from growing with the depth of tail recursion.
+type tailCall struct {
+	call     *ast.CallExpression
+	function object.Object
+	args     []object.Object
+}
+
+func (call *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (call *tailCall) Inspect() string         { return call.call.String() }
+
+// evalCall evaluates the function and the arguments of the call, but does not call the function yet. The result is
+// either a *tailCall or an error.
+func evalCall(call *ast.CallExpression, env *object.Environment) object.Object {
+	function := Eval(call.Function, env)
+	if stopsEvaluation(function) {
+		return function
+	}
+
+	args := evalExpressions(call.Arguments, env)
+	if len(args) == 1 && stopsEvaluation(args[0]) {
+		return args[0]
+	}
+
+	return &tailCall{call: call, function: function, args: args}
+}
+
+// evalTailStatement evaluates the last statement of a function body. Calls in tail position, including those in
+// the last statements of if and else blocks, are not run, but returned as a *tailCall.
+func evalTailStatement(statement ast.Statement, env *object.Environment) object.Object {
+	expressionStatement, ok := statement.(*ast.ExpressionStatement)
+	if !ok {
+		return Eval(statement, env)
+	}
+
+	switch expression := expressionStatement.Expression.(type) {
+	case *ast.CallExpression:
+		return evalCall(expression, env)
+	case *ast.IfExpression:
+		return evalIfExpression(expression, env, true)
+	default:
+		return Eval(expression, env)
+	}
+}
+
+// runTailCalls makes pending calls until one of them produces a value. Anything else than a *tailCall is
+// returned as is.
+func runTailCalls(obj object.Object) object.Object {
+	for {
+		pending, ok := obj.(*tailCall)
+		if !ok {
+			return obj
+		}
+
+		obj = applyFunction(pending.call, pending.function, pending.args)
+	}
+}
+
+// applyFunction calls the function with the evaluated arguments. Errors about the call itself are positioned at the
+// call expression, so they can be told apart from errors raised inside the function body. A call in tail position
+// of the body is not made here, it is returned as a *tailCall for runTailCalls.
+func applyFunction(call *ast.CallExpression, fn object.Object, args []object.Object) object.Object {
+	function, ok := fn.(*object.Function)
+	if !ok {
+		return newErrorAt(call.Function, "Not a function: %s", fn.Type())
+	}
+
+	if len(args) != len(function.Parameters) {
+		return newErrorAt(call, "Wrong number of arguments: expected %d, got %d", len(function.Parameters), len(args))
+	}
+
+	extendedEnv := extendFunctionEnv(function, args)
+	evaluated := evalBlockStatement(function.Body, extendedEnv, true)
+
+	return unwrapReturnValue(evaluated)
+}
+
+// extendFunctionEnv creates the scope of a single call. It encloses the environment the function was defined in,
+// not the one it is called from, which is what makes closures work.
+func extendFunctionEnv(function *object.Function, args []object.Object) *object.Environment {
+	env := object.NewEnclosedEnvironment(function.Env)
+
+	for i, param := range function.Parameters {
+		env.Set(param.Value, args[i])
+	}
+
+	return env
+}
+
+// unwrapReturnValue stops a return statement from bubbling up past the function it was used in.
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+
+	return obj
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+// isTruthy reports whether the object is considered true in a condition. Only false and null are falsy.
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL:
+		return false
+	case TRUE:
+		return true
+	case FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+// newErrorAt creates an error located at the given node
+func newErrorAt(node ast.AstNode, format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...), Pos: node.Pos(), End: node.End()}
+}
+
+// stopsEvaluation reports whether the object ends the evaluation of the enclosing expression. Besides errors these
+// are the return, break and continue signals, which a block used as a value (e.g. an if expression) can produce.
+// They bubble up to the statement handling them, just like errors.
+func stopsEvaluation(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+
+	switch obj.Type() {
+	case object.ERROR_OBJ, object.RETURN_VALUE_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+		return true
+	default:
+		return false
+	}
+}
+
+func isError(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ERROR_OBJ
+	}
+	return false
+}
@@ -0,0 +1,1027 @@
+package evaluator
+
+import (
+	"github.com/jpiechowka/micron-language-interpreter-go/lexer"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+	"github.com/jpiechowka/micron-language-interpreter-go/parser"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEvalIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"1337", 1337},
+		{"-5", -5},
+		{"-1337", -1337},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"3 * 3 * 3 + 10", 37},
+		{"7 / 2", 3},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"0xFF", 255},
+		{"0o755", 493},
+		{"0b1010", 10},
+		{"1_000_000 + 0x_10", 1000016},
+		{"9223372036854775807", 9223372036854775807},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"7 % -3", 1},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"5 ** 0", 1},
+		{"2 * 3 ** 2 % 5", 3},
+		{"12 & 10", 8},
+		{"12 | 10", 14},
+		{"12 ^ 10", 6},
+		{"~5", -6},
+		{"1 << 10", 1024},
+		{"-16 >> 2", -4},
+		{"1 + 1 << 2", 5},
+		{"1 | 2 ^ 3 & 6", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.5", 3.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3},
+		{"1 + 0.5", 1.5},
+		{"0.5 + 1", 1.5},
+		{"10 - 0.25", 9.75},
+		{"2 * 1.5e2", 300},
+		{"7 / 2.0", 3.5},
+		{"1.0 / 4", 0.25},
+		{"(1.5 + 2) * 2", 7},
+		{"7.5 % 2", 1.5},
+		{"-7.5 % 2", -1.5},
+		{"2 ** -1", 0.5},
+		{"4 ** 0.5", 2},
+		{"1.5 ** 2", 2.25},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 < 1", false},
+		{"1 > 1", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1 == 2", false},
+		{"1 != 2", true},
+		{"true == true", true},
+		{"false == false", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"false != true", true},
+		{"1 < 2 == true", true},
+		{"1 < 2 == false", false},
+		{"1 > 2 == true", false},
+		{"1 > 2 == false", true},
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"1 == 1.0", true},
+		{"0.1 + 0.2 != 0.3", true},
+		{"2.5 == 2.5", true},
+		{"1 <= 1", true},
+		{"1 <= 0", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"1.5 <= 2", true},
+		{"2 >= 2.5", false},
+		{`"a" <= "a"`, true},
+		{`"b" >= "c"`, false},
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 && 0", true},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBangOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!true", false},
+		{"!false", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!!false", false},
+		{"!!5", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	input := `"Hello World!"`
+
+	evaluated := testEval(input)
+
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("Object is not String. Got %T (%+v) instead", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. Got %q instead", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	input := `let greet = fn(name) { "Hello" + ", " + name + "!" }; greet("Micron")`
+
+	evaluated := testEval(input)
+
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("Object is not String. Got %T (%+v) instead", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello, Micron!" {
+		t.Errorf("String has wrong value. Got %q instead", str.Value)
+	}
+}
+
+func TestStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"abc" < "abd"`, true},
+		{`"b" > "abc"`, true},
+		{`"" < "a"`, true},
+		{`"a" + "b" == "ab"`, true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestShortCircuitEvaluation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		// The right operand would fail if it was evaluated
+		{"false && undefinedName", false},
+		{"true || undefinedName", true},
+		{"let fail = fn() { 1 / 0 }; false && fail()", false},
+		{"let fail = fn() { 1 / 0 }; true || fail()", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+
+	evaluated := testEval("true && undefinedName")
+	if !isError(evaluated) {
+		t.Errorf("Right operand was not evaluated when needed. Got %T (%+v) instead", evaluated, evaluated)
+	}
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", nil},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (true) { }", nil},
+		{"let x = 5; if (x > 1) { let y = x * 2; y } else { 0 }", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5 + true;", "Type mismatch: INTEGER + BOOLEAN"},
+		{"5 + true; 5;", "Type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "Unknown operator: -BOOLEAN"},
+		{"true + false;", "Unknown operator: BOOLEAN + BOOLEAN"},
+		{"5; true + false; 5", "Unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "Identifier not found: foobar"},
+		{"10 / 0", "Division by zero: 10 / 0"},
+		{"1.5 / 0", "Division by zero: 1.5 / 0"},
+		{"1 / 0.0", "Division by zero: 1 / 0.0"},
+		{"10 % 0", "Modulo by zero: 10 % 0"},
+		{"1.5 % 0", "Modulo by zero: 1.5 % 0"},
+		{"[1, 2, 3][3]", "Index out of range: 3 with length 3"},
+		{"[1, 2, 3][-4]", "Index out of range: -4 with length 3"},
+		{"[][0]", "Index out of range: 0 with length 0"},
+		{"[1, 2][true]", "Index operator not supported: ARRAY[BOOLEAN]"},
+		{"1[0]", "Index operator not supported: INTEGER[INTEGER]"},
+		{"[1, 2, 3][2:1]", "Slice bounds out of range: [2:1] with length 3"},
+		{"[1, 2, 3][:4]", "Slice bounds out of range: [0:4] with length 3"},
+		{"[1, 2, 3][-4:]", "Slice bounds out of range: [-4:3] with length 3"},
+		{"[1, 2, 3][\"a\":]", "Slice bound must be an integer, got STRING"},
+		{"5[1:]", "Slice operator not supported: INTEGER"},
+		{"[1, foo]", "Identifier not found: foo"},
+		{`{"name": "Micron"}[fn(x) { x }];`, "Unusable as hash key: FUNCTION"},
+		{`{[1]: 2}`, "Unusable as hash key: ARRAY"},
+		{`{1.5: 2}`, "Unusable as hash key: FLOAT"},
+		{`{"a": foo}`, "Identifier not found: foo"},
+		{"for (x in 5) { x }", "Cannot iterate over INTEGER"},
+		{"for (x in [1, 2]) { x + true }", "Type mismatch: INTEGER + BOOLEAN"},
+		{"while (foo) { 1 }", "Identifier not found: foo"},
+		{"1.5..2", "Unknown operator: FLOAT .. INTEGER"},
+		{"x = 5", "Assignment to undeclared identifier: x"},
+		{"let f = fn() { y = 1 }; f()", "Assignment to undeclared identifier: y"},
+		{"let x = 1; x += true", "Type mismatch: INTEGER + BOOLEAN"},
+		{"let x = 1; x /= 0", "Division by zero: 1 / 0"},
+		{"let a = [1]; a[1] = 2", "Index out of range: 1 with length 1"},
+		{"let a = [1]; a[-2] += 2", "Index out of range: -2 with length 1"},
+		{`let a = [1]; a["0"] = 2`, "Index operator not supported: ARRAY[STRING]"},
+		{`let h = {}; h["k"] += 1`, "Key not found: k"},
+		{"let h = {}; h[[1]] = 1", "Unusable as hash key: ARRAY"},
+		{`let s = "abc"; s[0] = "d"`, "Index assignment not supported: STRING"},
+		{"const a = [1]; a[0] = 2", "Cannot modify frozen ARRAY"},
+		{`const h = {"k": [1]}; h["k"][0] = 2`, "Cannot modify frozen ARRAY"},
+		{`const h = {}; h["k"] = 2`, "Cannot modify frozen HASH"},
+		{"let a = [1]; const b = a; b[0] = 2", "Cannot modify frozen ARRAY"},
+		{"let f = fn() { x = 2 }; const x = 1; f()", "Cannot assign to constant x"},
+		{"1 << -1", "Negative shift count: 1 << -1"},
+		{"1 >> 64", "Shift count too large: 1 >> 64"},
+		{"1.5 & 1", "Unknown operator: FLOAT & INTEGER"},
+		{"~true", "Unknown operator: ~BOOLEAN"},
+		{"~1.5", "Unknown operator: ~FLOAT"},
+		{"1.5 + true", "Type mismatch: FLOAT + BOOLEAN"},
+		{"let x = 5; x(1)", "Not a function: INTEGER"},
+		{`"Hello" - "World"`, "Unknown operator: STRING - STRING"},
+		{`"Hello" + 1`, "Type mismatch: STRING + INTEGER"},
+		{"let add = fn(a, b) { a + b }; add(1)", "Wrong number of arguments: expected 2, got 1"},
+		{"if (10 > 1) { true + false; }", "Unknown operator: BOOLEAN + BOOLEAN"},
+		{`
+if (10 > 1) {
+	if (10 > 1) {
+		return true + false;
+	}
+
+	return 1;
+}
+`, "Unknown operator: BOOLEAN + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errorObject, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("No error object returned. Got %T (%+v) instead", evaluated, evaluated)
+			continue
+		}
+
+		if errorObject.Message != tt.expectedMessage {
+			t.Errorf("Wrong error message. Expected %q, got %q instead", tt.expectedMessage, errorObject.Message)
+		}
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+		{"let a = 5\nlet b = a * 3\nb", 15},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"let a = 5; return a * 2; a", 10},
+		{`
+if (10 > 1) {
+	if (10 > 1) {
+		return 10;
+	}
+
+	return 1;
+}
+`, 10},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	input := "fn(x) { x + 2; };"
+
+	evaluated := testEval(input)
+
+	function, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("Object is not Function. Got %T (%+v) instead", evaluated, evaluated)
+	}
+
+	if len(function.Parameters) != 1 {
+		t.Fatalf("Function has wrong parameters. Parameters: %+v", function.Parameters)
+	}
+
+	if function.Parameters[0].String() != "x" {
+		t.Fatalf("Parameter is not 'x'. Got %q instead", function.Parameters[0])
+	}
+
+	expectedBody := "(x + 2)"
+	if function.Body.String() != expectedBody {
+		t.Fatalf("Body is not %q. Got %q instead", expectedBody, function.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let early = fn() { return 1; 2; }; early() + 1;", 2},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestClosures(t *testing.T) {
+	input := `
+let newAdder = fn(x) {
+	fn(y) { x + y };
+};
+
+let addTwo = newAdder(2);
+addTwo(2);`
+
+	testIntegerObject(t, testEval(input), 4)
+}
+
+func TestClosuresCaptureTheirScope(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		// Each call of counter creates a new n, which the returned function keeps updating
+		{"let counter = fn() { let n = 0; fn() { n = n + 1; n } }; let c = counter(); c(); c(); c()", 3},
+		{"let counter = fn() { let n = 0; fn() { n = n + 1; n } }; let a = counter(); let b = counter(); a(); a(); b()", 1},
+		// Closures sharing a scope see each other's updates
+		{`let account = fn() {
+			let balance = 0;
+			{"deposit": fn(amount) { balance += amount }, "balance": fn() { balance }}
+		};
+		let acc = account(); acc["deposit"](10); acc["deposit"](5); acc["balance"]()`, 15},
+		// Names are resolved when the function runs, not when it is defined
+		{"let x = 1; let f = fn() { x }; x = 2; f()", 2},
+		// Every loop iteration has its own scope, so closures capture the value of that iteration
+		{"let fs = [0, 0, 0]; for (i in 0..3) { fs[i] = fn() { i * 10 }; } fs[0]() + fs[2]()", 20},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestRecursion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let fact = fn(n) { if (n <= 1) { 1 } else { n * fact(n - 1) } }; fact(10)", 3628800},
+		{"let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(15)", 610},
+		{`let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+		let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+		if (isEven(10) && isOdd(7)) { 1 } else { 0 }`, 1},
+		// The recursive function resolves its own name from a closure
+		{"let make = fn() { let loop = fn(n, acc) { if (n == 0) { acc } else { loop(n - 1, acc + n) } }; loop }; make()(100, 0)", 5050},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestHigherOrderFunctions(t *testing.T) {
+	definitions := `
+		let map = fn(array, f) {
+			let result = array[:];
+			let i = 0;
+			for (element in array) { result[i] = f(element); i += 1; }
+			result
+		};
+		let reduce = fn(array, initial, f) {
+			let accumulator = initial;
+			for (element in array) { accumulator = f(accumulator, element); }
+			accumulator
+		};
+		let compose = fn(f, g) { fn(x) { f(g(x)) } };
+		let twice = fn(f) { compose(f, f) };
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map([1, 2, 3], fn(x) { x * 2 })", "[2, 4, 6]"},
+		{"reduce([1, 2, 3, 4], 0, fn(sum, x) { sum + x })", "10"},
+		{"reduce(map([1, 2, 3], fn(x) { x * x }), 0, fn(a, b) { a + b })", "14"},
+		{"compose(fn(x) { x + 1 }, fn(x) { x * 10 })(5)", "51"},
+		{"twice(twice(fn(x) { x + 3 }))(0)", "12"},
+		{"let factor = 3; map([1, 2], fn(x) { x * factor })", "[3, 6]"},
+		{"map([fn(x) { x + 1 }, fn(x) { x - 1 }], fn(f) { f(10) })", "[11, 9]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(definitions + tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("Wrong result for %q. Expected %s, got %s instead", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	// Without tail calls every level of the recursion takes several frames of the Go stack, so even the shallower
+	// cases need far more than this limit. With them, the stack does not grow with the depth of the recursion.
+	defer debug.SetMaxStack(debug.SetMaxStack(16 << 20))
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let countdown = fn(n) { if (n == 0) { return 0; } countdown(n - 1) }; countdown(1000000)", 0},
+		{"let countdown = fn(n) { if (n == 0) { 0 } else { countdown(n - 1) } }; countdown(200000)", 0},
+		{"let countdown = fn(n) { if (n > 0) { return countdown(n - 1); } n }; countdown(200000)", 0},
+		{"let sum = fn(n, acc) { if (n == 0) { acc } else { sum(n - 1, acc + n) } }; sum(200000, 0)", 20000100000},
+		{`let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+		let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+		isEven(200000)`, true},
+		{"let loop = fn(n) { while (true) { if (n == 0) { return 7; } return loop(n - 1); } }; loop(200000)", 7},
+		{"let countdown = fn(n) { if (n == 0) { return 0; } countdown(n - 1) }; return countdown(200000);", 0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestTailCallErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedPos     string
+	}{
+		{"let f = fn(n) { g(n, 1) }; let g = fn(n) { n }; f(1)", "Wrong number of arguments: expected 1, got 2", "1:17"},
+		{"let f = fn(n) { return n(); }; f(1)", "Not a function: INTEGER", "1:24"},
+		{"let f = fn(n) { if (n == 0) { n + true } else { f(n - 1) } }; f(100000)", "Type mismatch: INTEGER + BOOLEAN", "-"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errorObject, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("No error object returned. Got %T (%+v) instead", evaluated, evaluated)
+			continue
+		}
+
+		if errorObject.Message != tt.expectedMessage || errorObject.Pos.String() != tt.expectedPos {
+			t.Errorf("Wrong error. Expected %s %q, got %s %q instead", tt.expectedPos, tt.expectedMessage, errorObject.Pos, errorObject.Message)
+		}
+	}
+}
+
+func TestCallErrorPositions(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedPos     string
+		expectedEnd     string
+	}{
+		{"let add = fn(a, b) { a + b };\nadd(1)", "Wrong number of arguments: expected 2, got 1", "2:1", "2:7"},
+		{"let add = fn(a, b) { a + b };\nlet x = add(1, 2, 3)", "Wrong number of arguments: expected 2, got 3", "2:9", "2:21"},
+		{"let f = fn() { fn(x) { x } };\n  f()()", "Wrong number of arguments: expected 1, got 0", "2:3", "2:8"},
+		{"let x = 5; x(1)", "Not a function: INTEGER", "1:12", "1:13"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errorObject, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("No error object returned. Got %T (%+v) instead", evaluated, evaluated)
+			continue
+		}
+
+		if errorObject.Message != tt.expectedMessage {
+			t.Errorf("Wrong error message. Expected %q, got %q instead", tt.expectedMessage, errorObject.Message)
+		}
+
+		if errorObject.Pos.String() != tt.expectedPos || errorObject.End.String() != tt.expectedEnd {
+			t.Errorf("Wrong error position for %q. Expected %s-%s, got %s-%s instead", tt.input, tt.expectedPos, tt.expectedEnd, errorObject.Pos, errorObject.End)
+		}
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	evaluated := testEval(input)
+
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("Object is not Array. Got %T (%+v) instead", evaluated, evaluated)
+	}
+
+	if len(result.Elements) != 3 {
+		t.Fatalf("Array has wrong number of elements. Got %d instead", len(result.Elements))
+	}
+
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[[1, 2], [3, 4]][1][0]", 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestArraySliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4][1:]", "[2, 3, 4]"},
+		{"[1, 2, 3, 4][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4][:]", "[1, 2, 3, 4]"},
+		{"[1, 2, 3, 4][-2:]", "[3, 4]"},
+		{"[1, 2, 3, 4][:-1]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4][2:2]", "[]"},
+		{"[1, 2, 3, 4][4:]", "[]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		result, ok := evaluated.(*object.Array)
+		if !ok {
+			t.Errorf("Object is not Array. Got %T (%+v) instead", evaluated, evaluated)
+			continue
+		}
+
+		if result.Inspect() != tt.expected {
+			t.Errorf("Wrong slice for %q. Expected %s, got %s instead", tt.input, tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	evaluated := testEval(input)
+
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. Got %T (%+v) instead", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
+	}
+
+	pairs := result.Pairs()
+	if len(pairs) != len(expected) {
+		t.Fatalf("Hash has wrong number of pairs. Got %d instead", len(pairs))
+	}
+
+	// Iteration follows the order of the keys in the literal
+	for i, expectedPair := range expected {
+		if pairs[i].Key.(object.Hashable).HashKey() != expectedPair.key.HashKey() {
+			t.Errorf("Pair [%d/%d] has wrong key. Expected %s, got %s instead", i, len(expected), expectedPair.key.Inspect(), pairs[i].Key.Inspect())
+		}
+
+		testIntegerObject(t, pairs[i].Value, expectedPair.value)
+	}
+}
+
+func TestHashLiteralDuplicateKeys(t *testing.T) {
+	evaluated := testEval(`{"a": 1, "b": 2, "a": 3}`)
+
+	if evaluated.Inspect() != "{a: 3, b: 2}" {
+		t.Errorf("Wrong hash. Expected %q, got %q instead", "{a: 3, b: 2}", evaluated.Inspect())
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{"a": {"b": 7}}["a"]["b"]`, 7},
+		{`{"a": [1, 2, 3]}["a"][-1]`, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"while (false) { 1 }", nil},
+		{"while (true) { break; }", nil},
+		{"for (x in []) { x }", nil},
+		{"for (x in [1, 2, 3]) { x }", nil},
+		{"let f = fn() { while (true) { return 5; } }; f()", 5},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x > 1) { return x; } } }; f()", 2},
+		{"let f = fn() { for (x in 0..10) { if (x < 7) { continue; } return x; } }; f()", 7},
+		{"let f = fn() { for (x in 5..5) { return x; } 0 }; f()", 0},
+		{"let f = fn() { for (x in 0..3) { for (y in 10..20) { break; } return x; } }; f()", 0},
+		{"let f = fn() { for (x in 0..3) { for (y in 10..20) { continue; } } 9 }; f()", 9},
+		{"let x = 1; for (x in [5]) { } x", 1},
+		{"let f = fn() { for (n in 0..1000000) { } 1 }; f()", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestLoopSignalsInExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let f = fn() { for (x in 0..10) { let s = if (x > 3) { break } else { 0 }; if (x == 5) { return x } } 7 }; f()", 7},
+		{"let f = fn() { while (true) { let s = if (true) { break } else { 0 } } 4 }; f()", 4},
+		{"let f = fn() { for (x in 0..3) { let a = [x, if (x < 2) { continue }]; return x } }; f()", 2},
+		{"let g = fn(a, b) { a }; let f = fn() { for (x in 0..5) { g(x, if (x < 3) { continue }); return x } }; f()", 3},
+		{"let f = fn() { for (x in 0..5) { 1 + if (x < 4) { continue } else { 0 }; return x } }; f()", 4},
+		{"let f = fn() { for (x in 0..5) { [1, 2][if (x < 1) { continue } else { 0 }:]; return x } }; f()", 1},
+		{"let i = 0; while (i < 10) { i += 1; let s = if (i > 3) { break } else { 0 } } i", 4},
+		{"let i = 0; while (true) { i += 1; let s = if (i > 3) { break } else { 0 } } i", 4},
+		{"let i = 0; while (true) { i = if (i == 3) { break } else { i + 1 } } i", 3},
+		{"let n = 0; for (x in 1..6) { let a = [x, if (x % 2 == 0) { continue }]; n += x } n", 9},
+		{"let n = 0; for (x in 0..5) { n += 1 + if (x > 1) { continue } else { 0 } } n", 2},
+		{"let f = fn() { let y = if (true) { return 1 } else { 2 }; 5 }; f()", 1},
+		{"let f = fn() { 1 + if (true) { return 10 } }; f()", 10},
+		{"let f = fn() { [1, if (true) { return 7 }, 3]; 0 }; f()", 7},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestForLoopIterationOrder(t *testing.T) {
+	// firstNotIn returns the first value visited by the loop which is not excluded, so the k-th visited value is
+	// found by excluding the k-1 values visited before it
+	definitions := `
+		let contains = fn(array, value) { for (element in array) { if (element == value) { return true; } } false };
+		let firstNotIn = fn(iterable, excluded) { for (x in iterable) { if (!contains(excluded, x)) { return x; } } };
+	`
+
+	tests := []struct {
+		iterable string
+		expected []string
+	}{
+		{`[3, 1, 2]`, []string{"3", "1", "2"}},
+		{`{"b": 1, "a": 2, "c": 3}`, []string{"b", "a", "c"}},
+		{`{"b": 1, "a": 2, "b": 3}`, []string{"b", "a"}},
+		{`-2..2`, []string{"-2", "-1", "0", "1"}},
+	}
+
+	for _, tt := range tests {
+		excluded := []string{}
+
+		for i, expected := range tt.expected {
+			input := definitions + "firstNotIn(" + tt.iterable + ", [" + strings.Join(excluded, ", ") + "])"
+
+			evaluated := testEval(input)
+			if evaluated.Inspect() != expected {
+				t.Errorf("Value [%d/%d] visited in %s is wrong. Expected %s, got %s instead", i, len(tt.expected), tt.iterable, expected, evaluated.Inspect())
+			}
+
+			if _, ok := evaluated.(*object.String); ok {
+				excluded = append(excluded, strconv.Quote(expected))
+			} else {
+				excluded = append(excluded, expected)
+			}
+		}
+
+		// All values are excluded now, so the loop finishes without returning
+		input := definitions + "firstNotIn(" + tt.iterable + ", [" + strings.Join(excluded, ", ") + "])"
+		testNullObject(t, testEval(input))
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let x = 1; x = 2; x", 2},
+		{"let x = 1; x = x + 1", 2},
+		{"let x = 1; let y = 2; x = y = 3; x + y", 6},
+		{"let x = 10; x += 5; x", 15},
+		{"let x = 10; x -= 5; x", 5},
+		{"let x = 10; x *= 5; x", 50},
+		{"let x = 10; x /= 5; x", 2},
+		{"let x = 10; x %= 4; x", 2},
+		{"let x = 1; let f = fn() { x = 5 }; f(); x", 5},
+		{"let x = 1; let f = fn() { let x = 2; x = 5 }; f(); x", 1},
+		{"let a = [1, 2, 3]; a[0] = 10; a[0] + a[1]", 12},
+		{"let a = [1, 2, 3]; a[-1] += 10; a[2]", 13},
+		{"let a = [1, 2, 3]; let b = a; b[1] = 20; a[1]", 20},
+		{"let a = [1, 2, 3]; let b = a[:]; b[1] = 20; a[1]", 2},
+		{`let h = {"k": 1}; h["k"] = 5; h["k"]`, 5},
+		{`let h = {}; h["new"] = 7; h["new"]`, 7},
+		{`let h = {"k": 1}; h["k"] *= 3; h["k"]`, 3},
+		{`let h = {"a": [1, 2]}; h["a"][1] = 9; h["a"][1]`, 9},
+		{"let i = 0; while (i < 10) { i += 1; } i", 10},
+		{"let sum = 0; for (x in 1..101) { sum += x; } sum", 5050},
+		{"let sum = 0; for (x in 0..10) { if (x % 2 == 0) { continue; } if (x > 7) { break; } sum += x; } sum", 16},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestHashAssignmentKeepsInsertionOrder(t *testing.T) {
+	input := `let h = {"b": 1, "a": 2}; h["c"] = 3; h["b"] = 4; h`
+
+	evaluated := testEval(input)
+
+	if evaluated.Inspect() != "{b: 4, a: 2, c: 3}" {
+		t.Errorf("Wrong hash. Expected %q, got %q instead", "{b: 4, a: 2, c: 3}", evaluated.Inspect())
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"const x = 5; x", 5},
+		{"const x = 5; let f = fn() { let x = 10; x += 1; x }; f() + x", 16},
+		{"const x = 5; let f = fn(x) { x = x * 2; x }; f(3) + x", 11},
+		{"const x = 5; let sum = 0; for (x in 0..3) { sum += x; } sum + x", 8},
+		{"let x = 1; const y = x; x = 7; y", 1},
+		{"const a = [1, 2, 3]; let b = a[:]; b[0] = 10; a[0] + b[0]", 11},
+		{"let a = [1]; const b = a; a[0] = 2; a[0] * 10 + b[0]", 21},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+// The REPL parses every line separately, so the constants of previous lines are only known to the evaluator
+func TestConstantsAcrossPrograms(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"x = 2", "Cannot assign to constant x"},
+		{"x += 2", "Cannot assign to constant x"},
+		{"let x = 2", "Cannot redeclare constant x"},
+		{"const x = 2", "Cannot redeclare constant x"},
+		{"let f = fn() { x = 2 }; f()", "Cannot assign to constant x"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		Eval(parser.New(lexer.New("const x = 1;")).ParseProgram(), env)
+
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+
+		errorObject, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("No error object returned for %q. Got %T (%+v) instead", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errorObject.Message != tt.expectedMessage {
+			t.Errorf("Wrong error message. Expected %q, got %q instead", tt.expectedMessage, errorObject.Message)
+		}
+
+		value, _ := env.Get("x")
+		testIntegerObject(t, value, 1)
+	}
+}
+
+func testEval(input string) object.Object {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	env := object.NewEnvironment()
+
+	return Eval(program, env)
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("Object is not Integer. Got %T (%+v) instead", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("Object has wrong value. Expected %d, got %d instead", expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("Object is not Float. Got %T (%+v) instead", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("Object has wrong value. Expected %g, got %g instead", expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("Object is not Boolean. Got %T (%+v) instead", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("Object has wrong value. Expected %t, got %t instead", expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != NULL {
+		t.Errorf("Object is not NULL. Got %T (%+v) instead", obj, obj)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,47 @@
+package evaluator
+
+import "github.com/jpiechowka/micron-language-interpreter-go/object"
+
+// The operations below apply the semantics of the language to values which are already evaluated. They are shared
+// with the virtual machine, so compiled and evaluated programs produce the same results and errors. Failures are
+// returned as *object.Error.
+
+func PrefixOperation(operator string, right object.Object) object.Object {
+	return evalPrefixExpression(operator, right)
+}
+
+func InfixOperation(operator string, left object.Object, right object.Object) object.Object {
+	return evalInfixExpression(operator, left, right)
+}
+
+func IndexOperation(left object.Object, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// SliceOperation slices an array, omitted bounds are nil
+func SliceOperation(left object.Object, low object.Object, high object.Object) object.Object {
+	return evalSlice(left, low, high)
+}
+
+// IndexAssignment stores the value in the array or hash and returns it
+func IndexAssignment(left object.Object, index object.Object, value object.Object) object.Object {
+	return assignIndex(left, index, false, func(object.Object) object.Object { return value })
+}
+
+// CompoundIndexAssignment combines the current value in the array or hash with the value using the infix operator,
+// e.g. + for +=, stores the result and returns it
+func CompoundIndexAssignment(left object.Object, index object.Object, operator string, value object.Object) object.Object {
+	return assignIndex(left, index, true, func(current object.Object) object.Object {
+		return evalInfixExpression(operator, current, value)
+	})
+}
+
+// IsTruthy reports whether the object is considered true in a condition. Only false and null are falsy.
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
+}
+
+// NativeBoolToBooleanObject returns the shared TRUE or FALSE object
+func NativeBoolToBooleanObject(input bool) *object.Boolean {
+	return nativeBoolToBooleanObject(input)
+}
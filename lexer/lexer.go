@@ -3,25 +3,68 @@ package lexer
 import (
 	"fmt"
 	"github.com/jpiechowka/micron-language-interpreter-go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// Error codes of problems found by the lexer. They share the numbering with the parser diagnostics,
+// lexer errors use the E01xx range.
+const (
+	UNTERMINATED_STRING  = "E0101"
+	INVALID_ESCAPE       = "E0102"
+	INVALID_UTF8         = "E0103"
+	UNTERMINATED_COMMENT = "E0104"
+	MALFORMED_NUMBER     = "E0105"
+)
+
+// Error describes a problem found while tokenizing, e.g. an unterminated string literal. The lexer still
+// returns a usable token in such cases, so parsing can continue.
+type Error struct {
+	Code    string
+	Message string
+	Pos     token.Position
+	End     token.Position
+}
+
 type Lexer struct {
 	input            string // input string containing code
-	currentChar      byte   // current char under examination
-	currentPosition  int    // current position in input (points to the current char)
-	nextReadPosition int    // current reading position in input (after current char)
+	currentChar      rune   // current char under examination
+	currentWidth     int    // size of the current char in bytes
+	currentPosition  int    // current byte offset in input (points to the current char)
+	nextReadPosition int    // current reading byte offset in input (after current char)
+	line             int    // line of the current char, starting at 1
+	column           int    // column of the current char in runes, starting at 1
+	errors           []Error
 }
 
 func New(lexerInput string) *Lexer {
-	lexer := &Lexer{input: lexerInput}
+	lexer := &Lexer{input: lexerInput, line: 1}
 	lexer.readChar()
 	return lexer
 }
 
+// NextToken returns the next token from the input. Comments are not returned as tokens, instead they are attached
+// as trivia to the nearest token: comments following a token on the same line are its trailing trivia, all other
+// comments are the leading trivia of the token after them.
 func (lexer *Lexer) NextToken() token.Token {
+	leadingTrivia := lexer.readLeadingTrivia()
+
+	nextToken := lexer.readToken()
+	nextToken.LeadingTrivia = leadingTrivia
+
+	if nextToken.TokenType != token.EOF {
+		nextToken.TrailingTrivia = lexer.readTrailingTrivia()
+	}
+
+	return nextToken
+}
+
+func (lexer *Lexer) readToken() token.Token {
 	var nextToken token.Token
 
-	lexer.consumeWhitespaces()
+	startPosition := lexer.position()
 
 	switch lexer.currentChar {
 	case '=':
@@ -53,32 +96,162 @@ func (lexer *Lexer) NextToken() token.Token {
 	case ',':
 		nextToken = newToken(token.COMMA, lexer.currentChar)
 	case '+':
-		nextToken = newToken(token.PLUS, lexer.currentChar)
+		// Check for compound assignment "+="
+		if lexer.peekChar() == '=' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.PLUS_ASSIGN, literal)
+		} else {
+			nextToken = newToken(token.PLUS, lexer.currentChar)
+		}
 	case '-':
-		nextToken = newToken(token.MINUS, lexer.currentChar)
+		// Check for compound assignment "-="
+		if lexer.peekChar() == '=' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.MINUS_ASSIGN, literal)
+		} else {
+			nextToken = newToken(token.MINUS, lexer.currentChar)
+		}
 	case '{':
 		nextToken = newToken(token.LBRACE, lexer.currentChar)
 	case '}':
 		nextToken = newToken(token.RBRACE, lexer.currentChar)
+	case '[':
+		nextToken = newToken(token.LBRACKET, lexer.currentChar)
+	case ']':
+		nextToken = newToken(token.RBRACKET, lexer.currentChar)
+	case ':':
+		nextToken = newToken(token.COLON, lexer.currentChar)
+	case '.':
+		// Only the range operator ".." is supported, a single '.' is illegal
+		if lexer.peekChar() == '.' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.DOT_DOT, literal)
+		} else {
+			nextToken = newToken(token.ILLEGAL, lexer.currentChar)
+		}
 	case '/':
-		nextToken = newToken(token.SLASH, lexer.currentChar)
+		// Check for compound assignment "/="
+		if lexer.peekChar() == '=' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.SLASH_ASSIGN, literal)
+		} else {
+			nextToken = newToken(token.SLASH, lexer.currentChar)
+		}
 	case '>':
-		nextToken = newToken(token.GREATERTHAN, lexer.currentChar)
+		// Check for greater than or equal sign ">=" and right shift ">>"
+		if lexer.peekChar() == '=' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.GREATERTHAN_OR_EQUAL, literal)
+		} else if lexer.peekChar() == '>' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.SHIFT_RIGHT, literal)
+		} else {
+			nextToken = newToken(token.GREATERTHAN, lexer.currentChar)
+		}
 	case '<':
-		nextToken = newToken(token.LESSTHAN, lexer.currentChar)
+		// Check for less than or equal sign "<=" and left shift "<<"
+		if lexer.peekChar() == '=' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.LESSTHAN_OR_EQUAL, literal)
+		} else if lexer.peekChar() == '<' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.SHIFT_LEFT, literal)
+		} else {
+			nextToken = newToken(token.LESSTHAN, lexer.currentChar)
+		}
+	case '&':
+		// Check for logical and "&&", a single '&' is bitwise and
+		if lexer.peekChar() == '&' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.AND, literal)
+		} else {
+			nextToken = newToken(token.AMPERSAND, lexer.currentChar)
+		}
+	case '|':
+		// Check for logical or "||", a single '|' is bitwise or
+		if lexer.peekChar() == '|' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.OR, literal)
+		} else {
+			nextToken = newToken(token.PIPE, lexer.currentChar)
+		}
 	case '*':
-		nextToken = newToken(token.ASTERISK, lexer.currentChar)
+		// Check for exponentiation "**" and compound assignment "*="
+		if lexer.peekChar() == '*' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.POWER, literal)
+		} else if lexer.peekChar() == '=' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.ASTERISK_ASSIGN, literal)
+		} else {
+			nextToken = newToken(token.ASTERISK, lexer.currentChar)
+		}
+	case '%':
+		// Check for compound assignment "%="
+		if lexer.peekChar() == '=' {
+			previousChar := lexer.currentChar
+			lexer.readChar() // Proceed by one
+			literal := string(previousChar) + string(lexer.currentChar)
+			nextToken = newTwoCharToken(token.PERCENT_ASSIGN, literal)
+		} else {
+			nextToken = newToken(token.PERCENT, lexer.currentChar)
+		}
+	case '^':
+		nextToken = newToken(token.CARET, lexer.currentChar)
+	case '~':
+		nextToken = newToken(token.TILDE, lexer.currentChar)
+	case '"':
+		value, terminated := lexer.readString(startPosition)
+		nextToken.Literal = value
+		nextToken.TokenType = token.STRING
+		if !terminated {
+			// The newline or EOF ending an unterminated string is not a part of the token
+			nextToken.Pos = startPosition
+			nextToken.End = lexer.position()
+			return nextToken
+		}
 	case 0:
+		// EOF is an empty token, so it starts and ends at the same position
 		nextToken.Literal = ""
 		nextToken.TokenType = token.EOF
+		nextToken.Pos = startPosition
+		nextToken.End = startPosition
+		return nextToken
 	default:
 		if isLetter(lexer.currentChar) {
 			nextToken.Literal = lexer.readIdentifier()
 			nextToken.TokenType = token.LookupIdentifier(nextToken.Literal)
+			nextToken.Pos = startPosition
+			nextToken.End = lexer.position()
 			return nextToken
 		} else if isDigit(lexer.currentChar) {
-			nextToken.Literal = lexer.readInteger()
-			nextToken.TokenType = token.INT
+			nextToken.Literal, nextToken.TokenType = lexer.readNumber()
+			nextToken.Pos = startPosition
+			nextToken.End = lexer.position()
 			return nextToken
 		} else {
 			nextToken = newToken(token.ILLEGAL, lexer.currentChar)
@@ -86,31 +259,74 @@ func (lexer *Lexer) NextToken() token.Token {
 	}
 
 	lexer.readChar()
+
+	nextToken.Pos = startPosition
+	nextToken.End = lexer.position()
+
 	return nextToken
 }
 
+// GetErrors returns the errors found in the input consumed so far.
+func (lexer *Lexer) GetErrors() []Error {
+	return lexer.errors
+}
+
+func (lexer *Lexer) reportError(code string, start token.Position, end token.Position, format string, a ...interface{}) {
+	lexer.errors = append(lexer.errors, Error{Code: code, Message: fmt.Sprintf(format, a...), Pos: start, End: end})
+}
+
 func (lexer *Lexer) readChar() {
+	// Moving past a newline starts a new line, moving past any other char advances the column
+	if lexer.currentChar == '\n' {
+		lexer.line += 1
+		lexer.column = 1
+	} else {
+		lexer.column += 1
+	}
+
 	if lexer.nextReadPosition >= len(lexer.input) { // Check if end of input is reached
 		lexer.currentChar = 0 // 0 is NULL in ASCII
+		lexer.currentWidth = 0
 	} else {
-		lexer.currentChar = lexer.input[lexer.nextReadPosition]
+		lexer.currentChar, lexer.currentWidth = utf8.DecodeRuneInString(lexer.input[lexer.nextReadPosition:])
 	}
 
 	lexer.currentPosition = lexer.nextReadPosition
-	lexer.nextReadPosition += 1
+	lexer.nextReadPosition += lexer.currentWidth
+
+	if lexer.isInvalidChar() {
+		lexer.reportError(INVALID_UTF8, lexer.position(), lexer.endOfCurrentChar(), "Invalid UTF-8 encoding (byte 0x%02X)", lexer.input[lexer.currentPosition])
+	}
+}
+
+// isInvalidChar reports whether the current char is a byte which is not a part of a valid UTF-8 sequence. Decoding
+// such bytes results in utf8.RuneError, which on its own is a valid char if it is properly encoded in the input.
+func (lexer *Lexer) isInvalidChar() bool {
+	return lexer.currentChar == utf8.RuneError && lexer.currentWidth == 1
+}
+
+// position returns the position of the current char
+func (lexer *Lexer) position() token.Position {
+	return token.Position{Offset: lexer.currentPosition, Line: lexer.line, Column: lexer.column}
+}
+
+// endOfCurrentChar returns the position immediately after the current char, which must not be a newline
+func (lexer *Lexer) endOfCurrentChar() token.Position {
+	return token.Position{Offset: lexer.currentPosition + lexer.currentWidth, Line: lexer.line, Column: lexer.column + 1}
 }
 
 // peekChar is similar to readChar() but it doesn't increase currentPosition and nextReadPosition.
 // It will be used to check for symbols like "==" or "!=".
-func (lexer *Lexer) peekChar() byte {
+func (lexer *Lexer) peekChar() rune {
 	if lexer.nextReadPosition >= len(lexer.input) {
 		return 0
 	} else {
-		return lexer.input[lexer.nextReadPosition]
+		char, _ := utf8.DecodeRuneInString(lexer.input[lexer.nextReadPosition:])
+		return char
 	}
 }
 
-func newToken(tokenType token.TokenType, char byte) token.Token {
+func newToken(tokenType token.TokenType, char rune) token.Token {
 	return token.Token{TokenType: tokenType, Literal: string(char)}
 }
 
@@ -124,33 +340,355 @@ func newTwoCharToken(tokenType token.TokenType, literal string) token.Token {
 func (lexer *Lexer) readIdentifier() string {
 	startPosition := lexer.currentPosition
 
-	for isLetter(lexer.currentChar) {
+	for isLetter(lexer.currentChar) || unicode.IsDigit(lexer.currentChar) {
 		lexer.readChar()
 	}
 
 	return lexer.input[startPosition:lexer.currentPosition]
 }
 
-func (lexer *Lexer) readInteger() string {
-	startPosition := lexer.currentPosition
+// readNumber reads an integer or a float literal, e.g. 42, 1_000_000, 0xFF, 0o755, 0b1010, 3.14, 1e9 or 1.5e-3.
+// A dot has to be followed by a digit to be a part of the number. Malformed literals are reported here, the parser
+// only checks if the value fits into its type.
+func (lexer *Lexer) readNumber() (string, token.TokenType) {
+	startPosition := lexer.position()
+	tokenType := token.TokenType(token.INT)
+	errorCount := len(lexer.errors)
+
+	if lexer.currentChar == '0' && strings.ContainsRune("xXoObB", lexer.peekChar()) {
+		lexer.readPrefixedInteger(startPosition)
+	} else {
+		lexer.readDigits()
+
+		if lexer.currentChar == '.' && isDigit(lexer.peekChar()) {
+			tokenType = token.FLOAT
+			lexer.readChar()
+			lexer.readDigits()
+		}
+
+		if lexer.currentChar == 'e' || lexer.currentChar == 'E' {
+			tokenType = token.FLOAT
+			lexer.readExponent()
+		}
+	}
+
+	literal := lexer.input[startPosition.Offset:lexer.currentPosition]
+
+	// Only one problem is reported per literal, e.g. 0x_ has no digits, but the underscore is not reported as trailing
+	if len(lexer.errors) == errorCount {
+		lexer.validateUnderscores(literal, startPosition)
+	}
+
+	if len(lexer.errors) == errorCount && tokenType == token.INT && len(literal) > 1 && literal[0] == '0' && (isDigit(rune(literal[1])) || literal[1] == '_') {
+		lexer.reportError(MALFORMED_NUMBER, startPosition, lexer.position(),
+			"Leading zeros are not allowed in decimal literals, use the 0o prefix for octal numbers")
+	}
+
+	return literal, tokenType
+}
+
+// readPrefixedInteger reads a hexadecimal (0x), octal (0o) or binary (0b) integer literal. All alphanumeric chars
+// following the prefix are consumed, so a digit which is invalid for the base is reported instead of starting
+// another token.
+func (lexer *Lexer) readPrefixedInteger(startPosition token.Position) {
+	lexer.readChar()
+	prefix := unicode.ToLower(lexer.currentChar)
+	lexer.readChar()
+
+	baseName, isValidDigit := "hexadecimal", isHexDigit
+	switch prefix {
+	case 'o':
+		baseName, isValidDigit = "octal", func(char rune) bool { return '0' <= char && char <= '7' }
+	case 'b':
+		baseName, isValidDigit = "binary", func(char rune) bool { return char == '0' || char == '1' }
+	}
+
+	digitCount := 0
+	reportedInvalidDigit := false
+
+	for isHexDigit(lexer.currentChar) || lexer.currentChar == '_' {
+		if lexer.currentChar != '_' {
+			digitCount++
+
+			if !isValidDigit(lexer.currentChar) && !reportedInvalidDigit {
+				lexer.reportError(MALFORMED_NUMBER, lexer.position(), lexer.endOfCurrentChar(),
+					"Invalid digit %q in %s literal", lexer.currentChar, baseName)
+				reportedInvalidDigit = true
+			}
+		}
 
-	for isDigit(lexer.currentChar) {
 		lexer.readChar()
 	}
 
-	return lexer.input[startPosition:lexer.currentPosition]
+	if digitCount == 0 {
+		lexer.reportError(MALFORMED_NUMBER, startPosition, lexer.position(), "No digits in %s literal", baseName)
+	}
+}
+
+// readExponent reads the exponent of a float literal. The current char must be the 'e' or 'E'.
+func (lexer *Lexer) readExponent() {
+	exponentPosition := lexer.position()
+	lexer.readChar()
+
+	if lexer.currentChar == '+' || lexer.currentChar == '-' {
+		lexer.readChar()
+	}
+
+	if isDigit(lexer.currentChar) {
+		lexer.readDigits()
+	} else {
+		lexer.reportError(MALFORMED_NUMBER, exponentPosition, lexer.position(), "Exponent has no digits")
+	}
 }
 
-func isLetter(char byte) bool {
-	return ('a' <= char && char <= 'z') || ('A' <= char && char <= 'Z') || char == '_'
+// readDigits reads decimal digits, which can be separated by underscores
+func (lexer *Lexer) readDigits() {
+	for isDigit(lexer.currentChar) || lexer.currentChar == '_' {
+		lexer.readChar()
+	}
 }
 
-func isDigit(char byte) bool {
+// validateUnderscores checks that every underscore in the number literal separates two digits. The only exception
+// is an underscore directly after a base prefix, e.g. 0x_FF. Only the first misplaced underscore is reported.
+func (lexer *Lexer) validateUnderscores(literal string, startPosition token.Position) {
+	hasPrefix := len(literal) > 1 && literal[0] == '0' && strings.ContainsRune("xXoObB", rune(literal[1]))
+
+	// Invalid digits of prefixed literals are reported separately, so any alphanumeric char counts as a digit there
+	isDigitAt := func(i int) bool {
+		if hasPrefix {
+			return i >= 2 && isHexDigit(rune(literal[i]))
+		}
+		return isDigit(rune(literal[i]))
+	}
+
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '_' {
+			continue
+		}
+
+		// Number literals only contain ASCII chars, so byte offsets within the literal are also column offsets
+		underscorePosition := token.Position{Offset: startPosition.Offset + i, Line: startPosition.Line, Column: startPosition.Column + i}
+		underscoreEnd := token.Position{Offset: underscorePosition.Offset + 1, Line: underscorePosition.Line, Column: underscorePosition.Column + 1}
+
+		previousIsDigit := (i > 0 && isDigitAt(i-1)) || (hasPrefix && i == 2)
+		nextIsDigit := i+1 < len(literal) && isDigitAt(i+1)
+
+		switch {
+		case i+1 < len(literal) && literal[i+1] == '_':
+			lexer.reportError(MALFORMED_NUMBER, underscorePosition, underscoreEnd, "Consecutive underscores in number literal")
+			return
+		case i+1 == len(literal):
+			lexer.reportError(MALFORMED_NUMBER, underscorePosition, underscoreEnd, "Number literal cannot end with an underscore")
+			return
+		case !previousIsDigit || !nextIsDigit:
+			lexer.reportError(MALFORMED_NUMBER, underscorePosition, underscoreEnd, "Underscore must separate digits in number literal")
+			return
+		}
+	}
+}
+
+// isLetter reports whether the char can start an identifier. Identifiers can contain any unicode letters and,
+// after the first char, also unicode digits.
+func isLetter(char rune) bool {
+	return unicode.IsLetter(char) || char == '_'
+}
+
+// isDigit only accepts ASCII digits, as these are the only ones allowed in number literals
+func isDigit(char rune) bool {
 	return '0' <= char && char <= '9'
 }
 
+// consumeWhitespaces also skips bytes which are not valid UTF-8. They are reported when read, so they do not
+// need to produce illegal tokens which would be reported again by the parser.
 func (lexer *Lexer) consumeWhitespaces() {
-	for lexer.currentChar == ' ' || lexer.currentChar == '\t' || lexer.currentChar == '\n' || lexer.currentChar == '\r' {
+	for lexer.currentChar == ' ' || lexer.currentChar == '\t' || lexer.currentChar == '\n' || lexer.currentChar == '\r' || lexer.isInvalidChar() {
+		lexer.readChar()
+	}
+}
+
+// readString reads a double-quoted string literal and returns its value with escape sequences decoded. The current
+// char must be the opening quote. A string has to be closed on the same line it was opened, if it's not, the
+// string read so far is returned and terminated is false. Otherwise the current char is the closing quote.
+func (lexer *Lexer) readString(startPosition token.Position) (value string, terminated bool) {
+	var out strings.Builder
+
+	for {
+		lexer.readChar()
+
+		switch lexer.currentChar {
+		case '"':
+			return out.String(), true
+		case 0, '\n':
+			lexer.reportError(UNTERMINATED_STRING, startPosition, lexer.position(), "Unterminated string literal")
+			return out.String(), false
+		case '\\':
+			lexer.readEscapeSequence(&out)
+		default:
+			out.WriteRune(lexer.currentChar)
+		}
+	}
+}
+
+// readEscapeSequence decodes the escape sequence starting at the current backslash and leaves the lexer at its
+// last char. Invalid escape sequences are reported and skipped without consuming the closing quote.
+func (lexer *Lexer) readEscapeSequence(out *strings.Builder) {
+	startPosition := lexer.position()
+
+	switch lexer.peekChar() {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '"':
+		out.WriteByte('"')
+	case '\\':
+		out.WriteByte('\\')
+	case 'u':
+		lexer.readChar()
+		lexer.readUnicodeEscape(out, startPosition)
+		return
+	case 0, '\n':
+		// Reported as an unterminated string by the caller
+		return
+	default:
+		lexer.readChar()
+		lexer.reportError(INVALID_ESCAPE, startPosition, lexer.endOfCurrentChar(), "Invalid escape sequence \\%c", lexer.currentChar)
+		return
+	}
+
+	lexer.readChar()
+}
+
+// readUnicodeEscape decodes an escape sequence in the form of \u{1F600}, containing from 1 to 6 hex digits.
+// The current char must be the 'u'.
+func (lexer *Lexer) readUnicodeEscape(out *strings.Builder, startPosition token.Position) {
+	if lexer.peekChar() != '{' {
+		lexer.reportError(INVALID_ESCAPE, startPosition, lexer.endOfCurrentChar(), "Expected { after \\u in unicode escape sequence")
+		return
+	}
+
+	lexer.readChar()
+	digitsStart := lexer.nextReadPosition
+
+	for isHexDigit(lexer.peekChar()) {
 		lexer.readChar()
 	}
+
+	digits := lexer.input[digitsStart:lexer.nextReadPosition]
+
+	if lexer.peekChar() != '}' {
+		lexer.reportError(INVALID_ESCAPE, startPosition, lexer.endOfCurrentChar(), "Expected } to close unicode escape sequence")
+		return
+	}
+
+	lexer.readChar()
+
+	if len(digits) == 0 || len(digits) > 6 {
+		lexer.reportError(INVALID_ESCAPE, startPosition, lexer.endOfCurrentChar(), "Unicode escape sequence must contain from 1 to 6 hex digits")
+		return
+	}
+
+	codePoint, _ := strconv.ParseUint(digits, 16, 32)
+	if !utf8.ValidRune(rune(codePoint)) {
+		lexer.reportError(INVALID_ESCAPE, startPosition, lexer.endOfCurrentChar(), "Invalid unicode code point %s", strings.ToUpper(digits))
+		return
+	}
+
+	out.WriteRune(rune(codePoint))
+}
+
+func isHexDigit(char rune) bool {
+	return isDigit(char) || ('a' <= char && char <= 'f') || ('A' <= char && char <= 'F')
+}
+
+// readLeadingTrivia skips whitespaces and collects all comments before the next token
+func (lexer *Lexer) readLeadingTrivia() []token.Trivia {
+	var trivia []token.Trivia
+
+	for {
+		lexer.consumeWhitespaces()
+
+		if !lexer.isAtComment() {
+			return trivia
+		}
+
+		trivia = append(trivia, lexer.readComment())
+	}
+}
+
+// readTrailingTrivia collects the comments following the previous token on the same line. The newline itself
+// and everything after it is left for the leading trivia of the next token.
+func (lexer *Lexer) readTrailingTrivia() []token.Trivia {
+	var trivia []token.Trivia
+
+	for {
+		for lexer.currentChar == ' ' || lexer.currentChar == '\t' || lexer.currentChar == '\r' || lexer.isInvalidChar() {
+			lexer.readChar()
+		}
+
+		if !lexer.isAtComment() {
+			return trivia
+		}
+
+		comment := lexer.readComment()
+		trivia = append(trivia, comment)
+
+		if comment.TriviaType == token.LINE_COMMENT {
+			return trivia
+		}
+	}
+}
+
+func (lexer *Lexer) isAtComment() bool {
+	return lexer.currentChar == '/' && (lexer.peekChar() == '/' || lexer.peekChar() == '*')
+}
+
+// readComment reads a line comment (// ...) up to the end of the line or a block comment (/* ... */). Block comments
+// can be nested, so /* a /* b */ c */ is a single comment. The text of the comment includes its delimiters.
+func (lexer *Lexer) readComment() token.Trivia {
+	comment := token.Trivia{Pos: lexer.position()}
+	startPosition := lexer.currentPosition
+
+	if lexer.peekChar() == '/' {
+		comment.TriviaType = token.LINE_COMMENT
+
+		for lexer.currentChar != '\n' && lexer.currentChar != 0 {
+			lexer.readChar()
+		}
+	} else {
+		comment.TriviaType = token.BLOCK_COMMENT
+		lexer.readBlockComment(comment.Pos)
+	}
+
+	comment.Text = lexer.input[startPosition:lexer.currentPosition]
+	comment.End = lexer.position()
+
+	return comment
+}
+
+// readBlockComment consumes a block comment including its closing delimiter. The current char must be the
+// slash of the opening delimiter.
+func (lexer *Lexer) readBlockComment(startPosition token.Position) {
+	depth := 0
+
+	for lexer.currentChar != 0 {
+		if lexer.currentChar == '/' && lexer.peekChar() == '*' {
+			depth++
+			lexer.readChar()
+		} else if lexer.currentChar == '*' && lexer.peekChar() == '/' {
+			depth--
+			lexer.readChar()
+
+			if depth == 0 {
+				lexer.readChar()
+				return
+			}
+		}
+
+		lexer.readChar()
+	}
+
+	lexer.reportError(UNTERMINATED_COMMENT, startPosition, lexer.position(), "Unterminated block comment")
 }
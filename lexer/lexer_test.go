@@ -15,7 +15,7 @@ func TestNextToken(t *testing.T) {
 		};
 
 		let result = add(one, seven);
-        !-/*10;
+        !-/ *10;
         0 < 10 >   5;
 
         if (     5 <   10) {
@@ -26,6 +26,7 @@ func TestNextToken(t *testing.T) {
 
 		7777 == 5555;
         1111 != 33333333;
+		[1, 2][0:1];
 `
 
 	tests := []struct {
@@ -105,6 +106,17 @@ func TestNextToken(t *testing.T) {
 		{token.INEQUALITY, "!="},
 		{token.INT, "33333333"},
 		{token.SEMICOLON, ";"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RBRACKET, "]"},
+		{token.SEMICOLON, ";"},
 		{token.EOF, ""},
 	}
 
@@ -122,3 +134,580 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 10;\n  x == y\n"
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedStart token.Position
+		expectedEnd   token.Position
+	}{
+		{token.LET, token.Position{Offset: 0, Line: 1, Column: 1}, token.Position{Offset: 3, Line: 1, Column: 4}},
+		{token.IDENT, token.Position{Offset: 4, Line: 1, Column: 5}, token.Position{Offset: 5, Line: 1, Column: 6}},
+		{token.ASSIGN, token.Position{Offset: 6, Line: 1, Column: 7}, token.Position{Offset: 7, Line: 1, Column: 8}},
+		{token.INT, token.Position{Offset: 8, Line: 1, Column: 9}, token.Position{Offset: 10, Line: 1, Column: 11}},
+		{token.SEMICOLON, token.Position{Offset: 10, Line: 1, Column: 11}, token.Position{Offset: 11, Line: 1, Column: 12}},
+		{token.IDENT, token.Position{Offset: 14, Line: 2, Column: 3}, token.Position{Offset: 15, Line: 2, Column: 4}},
+		{token.EQUALITY, token.Position{Offset: 16, Line: 2, Column: 5}, token.Position{Offset: 18, Line: 2, Column: 7}},
+		{token.IDENT, token.Position{Offset: 19, Line: 2, Column: 8}, token.Position{Offset: 20, Line: 2, Column: 9}},
+		{token.EOF, token.Position{Offset: 21, Line: 3, Column: 1}, token.Position{Offset: 21, Line: 3, Column: 1}},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range tests {
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != tt.expectedType {
+			t.Fatalf("Lexer test case [%d/%d] failed - TokenType is wrong. Expected %s, got %s", i, len(tests), tt.expectedType, testedToken.TokenType)
+		}
+
+		if testedToken.Pos != tt.expectedStart {
+			t.Fatalf("Lexer test case [%d/%d] failed - Pos is wrong. Expected %+v, got %+v", i, len(tests), tt.expectedStart, testedToken.Pos)
+		}
+
+		if testedToken.End != tt.expectedEnd {
+			t.Fatalf("Lexer test case [%d/%d] failed - End is wrong. Expected %+v, got %+v", i, len(tests), tt.expectedEnd, testedToken.End)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+	}{
+		{`"foobar"`, "foobar"},
+		{`"foo bar"`, "foo bar"},
+		{`""`, ""},
+		{`"line\nbreak\ttab"`, "line\nbreak\ttab"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"back\\slash"`, `back\slash`},
+		{`"\u{48}\u{49}\u{1F600}"`, "HI\U0001F600"},
+		{`"zażółć"`, "zażółć"},
+	}
+
+	for i, tt := range tests {
+		lexer := New(tt.input)
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != token.STRING {
+			t.Fatalf("String test case [%d/%d] failed - TokenType is wrong. Expected %s, got %s", i, len(tests), token.STRING, testedToken.TokenType)
+		}
+
+		if testedToken.Literal != tt.expectedLiteral {
+			t.Fatalf("String test case [%d/%d] failed - Literal is wrong. Expected %q, got %q", i, len(tests), tt.expectedLiteral, testedToken.Literal)
+		}
+
+		if len(lexer.GetErrors()) != 0 {
+			t.Fatalf("String test case [%d/%d] failed - unexpected errors: %+v", i, len(tests), lexer.GetErrors())
+		}
+
+		if lexer.NextToken().TokenType != token.EOF {
+			t.Fatalf("String test case [%d/%d] failed - string was not fully consumed", i, len(tests))
+		}
+	}
+}
+
+func TestStringLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedCode    string
+		expectedMessage string
+		expectedStart   int
+		expectedEnd     int
+		expectedLiteral string
+	}{
+		{`"foo`, UNTERMINATED_STRING, "Unterminated string literal", 0, 4, "foo"},
+		{"\"foo\nbar\"", UNTERMINATED_STRING, "Unterminated string literal", 0, 4, "foo"},
+		{`"a\qb"`, INVALID_ESCAPE, `Invalid escape sequence \q`, 2, 4, "ab"},
+		{`"\u0041"`, INVALID_ESCAPE, `Expected { after \u in unicode escape sequence`, 1, 3, "0041"},
+		{`"\u{41"`, INVALID_ESCAPE, `Expected } to close unicode escape sequence`, 1, 6, ""},
+		{`"\u{}"`, INVALID_ESCAPE, `Unicode escape sequence must contain from 1 to 6 hex digits`, 1, 5, ""},
+		{`"\u{1234567}"`, INVALID_ESCAPE, `Unicode escape sequence must contain from 1 to 6 hex digits`, 1, 12, ""},
+		{`"\u{D800}"`, INVALID_ESCAPE, `Invalid unicode code point D800`, 1, 9, ""},
+	}
+
+	for i, tt := range tests {
+		lexer := New(tt.input)
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != token.STRING || testedToken.Literal != tt.expectedLiteral {
+			t.Errorf("String error test case [%d/%d] failed - expected STRING %q, got %s %q", i, len(tests), tt.expectedLiteral, testedToken.TokenType, testedToken.Literal)
+		}
+
+		errors := lexer.GetErrors()
+		if len(errors) != 1 {
+			t.Fatalf("String error test case [%d/%d] failed - expected 1 error, got %d: %+v", i, len(tests), len(errors), errors)
+		}
+
+		if errors[0].Code != tt.expectedCode || errors[0].Message != tt.expectedMessage {
+			t.Errorf("String error test case [%d/%d] failed - expected %s %q, got %s %q", i, len(tests), tt.expectedCode, tt.expectedMessage, errors[0].Code, errors[0].Message)
+		}
+
+		if errors[0].Pos.Offset != tt.expectedStart || errors[0].End.Offset != tt.expectedEnd {
+			t.Errorf("String error test case [%d/%d] failed - expected span %d-%d, got %d-%d", i, len(tests), tt.expectedStart, tt.expectedEnd, errors[0].Pos.Offset, errors[0].End.Offset)
+		}
+	}
+}
+
+func TestUnicodeInput(t *testing.T) {
+	input := `let café = "żółw"; λx1 € 変数`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedStart   token.Position
+		expectedEnd     token.Position
+	}{
+		{token.LET, "let", token.Position{Offset: 0, Line: 1, Column: 1}, token.Position{Offset: 3, Line: 1, Column: 4}},
+		{token.IDENT, "café", token.Position{Offset: 4, Line: 1, Column: 5}, token.Position{Offset: 9, Line: 1, Column: 9}},
+		{token.ASSIGN, "=", token.Position{Offset: 10, Line: 1, Column: 10}, token.Position{Offset: 11, Line: 1, Column: 11}},
+		{token.STRING, "żółw", token.Position{Offset: 12, Line: 1, Column: 12}, token.Position{Offset: 21, Line: 1, Column: 18}},
+		{token.SEMICOLON, ";", token.Position{Offset: 21, Line: 1, Column: 18}, token.Position{Offset: 22, Line: 1, Column: 19}},
+		{token.IDENT, "λx1", token.Position{Offset: 23, Line: 1, Column: 20}, token.Position{Offset: 27, Line: 1, Column: 23}},
+		{token.ILLEGAL, "€", token.Position{Offset: 28, Line: 1, Column: 24}, token.Position{Offset: 31, Line: 1, Column: 25}},
+		{token.IDENT, "変数", token.Position{Offset: 32, Line: 1, Column: 26}, token.Position{Offset: 38, Line: 1, Column: 28}},
+		{token.EOF, "", token.Position{Offset: 38, Line: 1, Column: 28}, token.Position{Offset: 38, Line: 1, Column: 28}},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range tests {
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != tt.expectedType || testedToken.Literal != tt.expectedLiteral {
+			t.Fatalf("Unicode test case [%d/%d] failed - expected %s %q, got %s %q", i, len(tests), tt.expectedType, tt.expectedLiteral, testedToken.TokenType, testedToken.Literal)
+		}
+
+		if testedToken.Pos != tt.expectedStart || testedToken.End != tt.expectedEnd {
+			t.Fatalf("Unicode test case [%d/%d] failed - expected span %+v-%+v, got %+v-%+v", i, len(tests), tt.expectedStart, tt.expectedEnd, testedToken.Pos, testedToken.End)
+		}
+	}
+
+	if len(lexer.GetErrors()) != 0 {
+		t.Fatalf("Lexer reported unexpected errors: %+v", lexer.GetErrors())
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	input := "let a\xff = \"b\xc3\";"
+
+	expectedTokens := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENT, "a"},
+		{token.ASSIGN, "="},
+		{token.STRING, "b\uFFFD"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range expectedTokens {
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != tt.expectedType || testedToken.Literal != tt.expectedLiteral {
+			t.Fatalf("Invalid UTF-8 test case [%d/%d] failed - expected %s %q, got %s %q", i, len(expectedTokens), tt.expectedType, tt.expectedLiteral, testedToken.TokenType, testedToken.Literal)
+		}
+	}
+
+	errors := lexer.GetErrors()
+	if len(errors) != 2 {
+		t.Fatalf("Lexer should report 2 errors. Got %d instead: %+v", len(errors), errors)
+	}
+
+	expectedErrors := []struct {
+		message string
+		offset  int
+		column  int
+	}{
+		{"Invalid UTF-8 encoding (byte 0xFF)", 5, 6},
+		{"Invalid UTF-8 encoding (byte 0xC3)", 11, 12},
+	}
+
+	for i, expected := range expectedErrors {
+		if errors[i].Code != INVALID_UTF8 || errors[i].Message != expected.message {
+			t.Errorf("Error %d is wrong. Expected %s %q, got %s %q", i, INVALID_UTF8, expected.message, errors[i].Code, errors[i].Message)
+		}
+
+		if errors[i].Pos.Offset != expected.offset || errors[i].Pos.Column != expected.column {
+			t.Errorf("Error %d has wrong position. Expected offset %d column %d, got %+v", i, expected.offset, expected.column, errors[i].Pos)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = 10 / 2; // trailing comment
+/* block /* nested */ comment */ x /* inline */ + 1
+// comment at the end`
+
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedLeading  []string
+		expectedTrailing []string
+	}{
+		{token.LET, "let", []string{"// leading comment"}, nil},
+		{token.IDENT, "x", nil, nil},
+		{token.ASSIGN, "=", nil, nil},
+		{token.INT, "10", nil, nil},
+		{token.SLASH, "/", nil, nil},
+		{token.INT, "2", nil, nil},
+		{token.SEMICOLON, ";", nil, []string{"// trailing comment"}},
+		{token.IDENT, "x", []string{"/* block /* nested */ comment */"}, []string{"/* inline */"}},
+		{token.PLUS, "+", nil, nil},
+		{token.INT, "1", nil, nil},
+		{token.EOF, "", []string{"// comment at the end"}, nil},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range tests {
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != tt.expectedType || testedToken.Literal != tt.expectedLiteral {
+			t.Fatalf("Comment test case [%d/%d] failed - expected %s %q, got %s %q", i, len(tests), tt.expectedType, tt.expectedLiteral, testedToken.TokenType, testedToken.Literal)
+		}
+
+		testTrivia(t, i, "leading", testedToken.LeadingTrivia, tt.expectedLeading)
+		testTrivia(t, i, "trailing", testedToken.TrailingTrivia, tt.expectedTrailing)
+	}
+
+	if len(lexer.GetErrors()) != 0 {
+		t.Fatalf("Lexer reported unexpected errors: %+v", lexer.GetErrors())
+	}
+}
+
+func TestCommentTriviaPositions(t *testing.T) {
+	lexer := New("x /* a\nb */ // c\ny")
+
+	x := lexer.NextToken()
+	if len(x.TrailingTrivia) != 2 {
+		t.Fatalf("Token should have 2 trailing comments. Got %d instead", len(x.TrailingTrivia))
+	}
+
+	block := x.TrailingTrivia[0]
+	if block.TriviaType != token.BLOCK_COMMENT || block.Pos.String() != "1:3" || block.End.String() != "2:5" {
+		t.Errorf("Block comment is wrong. Got %s from %s to %s", block.TriviaType, block.Pos, block.End)
+	}
+
+	line := x.TrailingTrivia[1]
+	if line.TriviaType != token.LINE_COMMENT || line.Pos.String() != "2:6" || line.End.String() != "2:10" {
+		t.Errorf("Line comment is wrong. Got %s from %s to %s", line.TriviaType, line.Pos, line.End)
+	}
+
+	y := lexer.NextToken()
+	if y.Literal != "y" || len(y.LeadingTrivia) != 0 {
+		t.Errorf("Expected token y without leading comments. Got %q with %d comments", y.Literal, len(y.LeadingTrivia))
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	lexer := New("x /* a /* b */")
+
+	lexer.NextToken()
+
+	if lexer.NextToken().TokenType != token.EOF {
+		t.Fatalf("Unterminated comment should extend to the end of the input")
+	}
+
+	errors := lexer.GetErrors()
+	if len(errors) != 1 || errors[0].Code != UNTERMINATED_COMMENT || errors[0].Pos.Offset != 2 {
+		t.Fatalf("Expected a single %s error at offset 2. Got %+v instead", UNTERMINATED_COMMENT, errors)
+	}
+}
+
+func testTrivia(t *testing.T, testCase int, kind string, trivia []token.Trivia, expected []string) {
+	if len(trivia) != len(expected) {
+		t.Fatalf("Comment test case [%d] failed - expected %d %s comments, got %d: %+v", testCase, len(expected), kind, len(trivia), trivia)
+	}
+
+	for i, comment := range trivia {
+		if comment.Text != expected[i] {
+			t.Errorf("Comment test case [%d] failed - %s comment is wrong. Expected %q, got %q", testCase, kind, expected[i], comment.Text)
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{"42", token.INT, "42"},
+		{"3.14", token.FLOAT, "3.14"},
+		{"0.5", token.FLOAT, "0.5"},
+		{"1e9", token.FLOAT, "1e9"},
+		{"1E9", token.FLOAT, "1E9"},
+		{"1.5e-3", token.FLOAT, "1.5e-3"},
+		{"2.5E+10", token.FLOAT, "2.5E+10"},
+		{"0", token.INT, "0"},
+		{"1_000_000", token.INT, "1_000_000"},
+		{"0xFF", token.INT, "0xFF"},
+		{"0XdeadBEEF", token.INT, "0XdeadBEEF"},
+		{"0x_ff_ff", token.INT, "0x_ff_ff"},
+		{"0o755", token.INT, "0o755"},
+		{"0b1010_0101", token.INT, "0b1010_0101"},
+		{"1_000.000_1", token.FLOAT, "1_000.000_1"},
+		{"0.5e1_0", token.FLOAT, "0.5e1_0"},
+	}
+
+	for i, tt := range tests {
+		lexer := New(tt.input)
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != tt.expectedType || testedToken.Literal != tt.expectedLiteral {
+			t.Fatalf("Number test case [%d/%d] failed - expected %s %q, got %s %q", i, len(tests), tt.expectedType, tt.expectedLiteral, testedToken.TokenType, testedToken.Literal)
+		}
+
+		if lexer.NextToken().TokenType != token.EOF || len(lexer.GetErrors()) != 0 {
+			t.Fatalf("Number test case [%d/%d] failed - number was not fully consumed or errors were reported: %+v", i, len(tests), lexer.GetErrors())
+		}
+	}
+}
+
+func TestMalformedExponent(t *testing.T) {
+	lexer := New("1.5e+;")
+
+	testedToken := lexer.NextToken()
+	if testedToken.TokenType != token.FLOAT || testedToken.Literal != "1.5e+" {
+		t.Fatalf("Expected FLOAT %q, got %s %q", "1.5e+", testedToken.TokenType, testedToken.Literal)
+	}
+
+	errors := lexer.GetErrors()
+	if len(errors) != 1 || errors[0].Code != MALFORMED_NUMBER || errors[0].Pos.Offset != 3 || errors[0].End.Offset != 5 {
+		t.Fatalf("Expected a single %s error spanning offsets 3-5. Got %+v instead", MALFORMED_NUMBER, errors)
+	}
+}
+
+func TestMalformedNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedMessage string
+		expectedStart   int
+		expectedEnd     int
+	}{
+		{"0x", "0x", "No digits in hexadecimal literal", 0, 2},
+		{"0o_", "0o_", "No digits in octal literal", 0, 3},
+		{"0b", "0b", "No digits in binary literal", 0, 2},
+		{"0b1021", "0b1021", `Invalid digit '2' in binary literal`, 4, 5},
+		{"0o78", "0o78", `Invalid digit '8' in octal literal`, 3, 4},
+		{"1__0", "1__0", "Consecutive underscores in number literal", 1, 2},
+		{"100_", "100_", "Number literal cannot end with an underscore", 3, 4},
+		{"1_.5", "1_.5", "Underscore must separate digits in number literal", 1, 2},
+		{"1._5", "1", "", 0, 0},
+		{"1_e5", "1_e5", "Underscore must separate digits in number literal", 1, 2},
+		{"0123", "0123", "Leading zeros are not allowed in decimal literals, use the 0o prefix for octal numbers", 0, 4},
+		{"0_1", "0_1", "Leading zeros are not allowed in decimal literals, use the 0o prefix for octal numbers", 0, 3},
+	}
+
+	for i, tt := range tests {
+		lexer := New(tt.input)
+		testedToken := lexer.NextToken()
+
+		if testedToken.Literal != tt.expectedLiteral {
+			t.Errorf("Malformed number test case [%d/%d] failed - literal is wrong. Expected %q, got %q", i, len(tests), tt.expectedLiteral, testedToken.Literal)
+		}
+
+		errors := lexer.GetErrors()
+
+		if tt.expectedMessage == "" {
+			if len(errors) != 0 {
+				t.Errorf("Malformed number test case [%d/%d] failed - unexpected errors: %+v", i, len(tests), errors)
+			}
+			continue
+		}
+
+		if len(errors) != 1 {
+			t.Errorf("Malformed number test case [%d/%d] failed - expected 1 error, got %d: %+v", i, len(tests), len(errors), errors)
+			continue
+		}
+
+		if errors[0].Code != MALFORMED_NUMBER || errors[0].Message != tt.expectedMessage {
+			t.Errorf("Malformed number test case [%d/%d] failed - expected %s %q, got %s %q", i, len(tests), MALFORMED_NUMBER, tt.expectedMessage, errors[0].Code, errors[0].Message)
+		}
+
+		if errors[0].Pos.Offset != tt.expectedStart || errors[0].End.Offset != tt.expectedEnd {
+			t.Errorf("Malformed number test case [%d/%d] failed - expected span %d-%d, got %d-%d", i, len(tests), tt.expectedStart, tt.expectedEnd, errors[0].Pos.Offset, errors[0].End.Offset)
+		}
+	}
+}
+
+func TestComparisonAndLogicalOperators(t *testing.T) {
+	input := `a <= b >= c < d > e && f || g & h | i`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.LESSTHAN_OR_EQUAL, "<="},
+		{token.IDENT, "b"},
+		{token.GREATERTHAN_OR_EQUAL, ">="},
+		{token.IDENT, "c"},
+		{token.LESSTHAN, "<"},
+		{token.IDENT, "d"},
+		{token.GREATERTHAN, ">"},
+		{token.IDENT, "e"},
+		{token.AND, "&&"},
+		{token.IDENT, "f"},
+		{token.OR, "||"},
+		{token.IDENT, "g"},
+		{token.AMPERSAND, "&"},
+		{token.IDENT, "h"},
+		{token.PIPE, "|"},
+		{token.IDENT, "i"},
+		{token.EOF, ""},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range tests {
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != tt.expectedType || testedToken.Literal != tt.expectedLiteral {
+			t.Fatalf("Operator test case [%d/%d] failed - expected %s %q, got %s %q", i, len(tests), tt.expectedType, tt.expectedLiteral, testedToken.TokenType, testedToken.Literal)
+		}
+	}
+}
+
+func TestArithmeticAndBitwiseOperators(t *testing.T) {
+	input := `a % b ** c * d ^ ~e << f >> g <<= h`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "b"},
+		{token.POWER, "**"},
+		{token.IDENT, "c"},
+		{token.ASTERISK, "*"},
+		{token.IDENT, "d"},
+		{token.CARET, "^"},
+		{token.TILDE, "~"},
+		{token.IDENT, "e"},
+		{token.SHIFT_LEFT, "<<"},
+		{token.IDENT, "f"},
+		{token.SHIFT_RIGHT, ">>"},
+		{token.IDENT, "g"},
+		{token.SHIFT_LEFT, "<<"},
+		{token.ASSIGN, "="},
+		{token.IDENT, "h"},
+		{token.EOF, ""},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range tests {
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != tt.expectedType || testedToken.Literal != tt.expectedLiteral {
+			t.Fatalf("Operator test case [%d/%d] failed - expected %s %q, got %s %q", i, len(tests), tt.expectedType, tt.expectedLiteral, testedToken.TokenType, testedToken.Literal)
+		}
+	}
+}
+
+func TestLoopKeywordsAndRanges(t *testing.T) {
+	input := `while (x) { break; } for (i in 0..10) { continue; } .`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.WHILE, "while"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.BREAK, "break"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.FOR, "for"},
+		{token.LPAREN, "("},
+		{token.IDENT, "i"},
+		{token.IN, "in"},
+		{token.INT, "0"},
+		{token.DOT_DOT, ".."},
+		{token.INT, "10"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.CONTINUE, "continue"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.ILLEGAL, "."},
+		{token.EOF, ""},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range tests {
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != tt.expectedType || testedToken.Literal != tt.expectedLiteral {
+			t.Fatalf("Loop test case [%d/%d] failed - expected %s %q, got %s %q", i, len(tests), tt.expectedType, tt.expectedLiteral, testedToken.TokenType, testedToken.Literal)
+		}
+	}
+}
+
+func TestAssignmentOperators(t *testing.T) {
+	input := `const c = 0; x = 1; x += 2; x -= 3; x *= 4; x /= 5; x %= 6; x ** 2`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.CONST, "const"},
+		{token.IDENT, "c"},
+		{token.ASSIGN, "="},
+		{token.INT, "0"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "4"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PERCENT_ASSIGN, "%="},
+		{token.INT, "6"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.POWER, "**"},
+		{token.INT, "2"},
+		{token.EOF, ""},
+	}
+
+	lexer := New(input)
+
+	for i, tt := range tests {
+		testedToken := lexer.NextToken()
+
+		if testedToken.TokenType != tt.expectedType || testedToken.Literal != tt.expectedLiteral {
+			t.Fatalf("Assignment test case [%d/%d] failed - expected %s %q, got %s %q", i, len(tests), tt.expectedType, tt.expectedLiteral, testedToken.TokenType, testedToken.Literal)
+		}
+	}
+}
@@ -2,17 +2,70 @@ package main
 
 import (
 	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/compiler"
+	"github.com/jpiechowka/micron-language-interpreter-go/lexer"
+	"github.com/jpiechowka/micron-language-interpreter-go/parser"
 	"github.com/jpiechowka/micron-language-interpreter-go/repl"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/user"
 )
 
+const usage = `Usage:
+  micron                 start the interactive console
+  micron disasm <file>   print the bytecode the file compiles to
+`
+
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	printBanner()
 	printGreeting()
 	repl.Start(os.Stdin, os.Stdout)
 }
 
+// runCommand runs a subcommand and returns the exit code
+func runCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	switch args[0] {
+	case "disasm":
+		if len(args) != 2 {
+			fmt.Fprint(stderr, usage)
+			return 2
+		}
+		return disassemble(args[1], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "Unknown command: %s\n%s", args[0], usage)
+		return 2
+	}
+}
+
+func disassemble(path string, stdout io.Writer, stderr io.Writer) int {
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	programParser := parser.New(lexer.New(string(source)))
+	program := programParser.ParseProgram()
+	if len(programParser.GetErrors()) != 0 {
+		fmt.Fprint(stderr, programParser.GetErrors().Render(string(source)))
+		return 1
+	}
+
+	programCompiler := compiler.New()
+	if err := programCompiler.Compile(program); err != nil {
+		fmt.Fprintf(stderr, "Compilation failed: %s\n", err)
+		return 1
+	}
+
+	fmt.Fprint(stdout, programCompiler.Bytecode().String())
+	return 0
+}
+
 func printBanner() {
 	banner := `
    __  ____                 
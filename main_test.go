@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDisasmCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "micron")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeSource := func(name string, source string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	valid := writeSource("valid.mc", "let x = 5; x + 1")
+	invalid := writeSource("invalid.mc", "let x 5;")
+	missing := filepath.Join(dir, "missing.mc")
+
+	tests := []struct {
+		args             []string
+		expectedCode     int
+		expectedStdout   string
+		expectedInStderr string
+	}{
+		{[]string{"disasm", valid}, 0, `globals: x
+instructions:
+  0000 OpConstant 0 (5)
+  0003 OpDefineGlobal 0 (x)
+  0006 OpGetGlobal 0 (x)
+  0009 OpConstant 1 (1)
+  0012 OpAdd
+  0013 OpPop
+constants:
+  0: INTEGER 5
+  1: INTEGER 1
+`, ""},
+		{[]string{"disasm", missing}, 1, "", "missing.mc"},
+		{[]string{"disasm", invalid}, 1, "", "Expected next token to be =, got INT instead"},
+		{[]string{"disasm"}, 2, "", "Usage:"},
+		{[]string{"unknown"}, 2, "", "Unknown command: unknown"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+
+		code := runCommand(tt.args, &stdout, &stderr)
+
+		if code != tt.expectedCode {
+			t.Errorf("Exit code for %v is wrong. Expected %d, got %d instead", tt.args, tt.expectedCode, code)
+		}
+
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("Output for %v is wrong. Expected:\n%s\nGot:\n%s", tt.args, tt.expectedStdout, stdout.String())
+		}
+
+		if tt.expectedInStderr == "" && stderr.Len() != 0 {
+			t.Errorf("Unexpected errors for %v: %s", tt.args, stderr.String())
+		}
+
+		if !strings.Contains(stderr.String(), tt.expectedInStderr) {
+			t.Errorf("Errors for %v do not contain %q. Got %q instead", tt.args, tt.expectedInStderr, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package object
+
+// Environment binds names to values. Environments can be nested, e.g. for function calls,
+// in which case lookups that fail in the inner scope fall back to the outer one.
+type Environment struct {
+	store     map[string]Object
+	constants map[string]bool // names in store which are bound as constants
+	outer     *Environment
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{store: make(map[string]Object), constants: make(map[string]bool)}
+}
+
+// NewEnclosedEnvironment creates a new scope whose unresolved lookups are delegated to outer.
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// Get resolves the name in this scope first and then walks the chain of outer scopes.
+func (environment *Environment) Get(name string) (Object, bool) {
+	value, ok := environment.store[name]
+	if !ok && environment.outer != nil {
+		value, ok = environment.outer.Get(name)
+	}
+	return value, ok
+}
+
+// Set always binds the name in this scope, shadowing any binding with the same name in outer scopes.
+func (environment *Environment) Set(name string, value Object) Object {
+	environment.store[name] = value
+	delete(environment.constants, name)
+	return value
+}
+
+// SetConst binds the name in this scope like Set, but marks the binding as a constant. The name is bound to a frozen
+// copy of the value, so the constant cannot be modified through other bindings of the original value either, while
+// those bindings stay mutable. It returns the frozen value.
+func (environment *Environment) SetConst(name string, value Object) Object {
+	frozen := Freeze(value)
+	environment.store[name] = frozen
+	environment.constants[name] = true
+	return frozen
+}
+
+// IsConst reports whether the nearest binding of the name is a constant
+func (environment *Environment) IsConst(name string) bool {
+	for env := environment; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			return env.constants[name]
+		}
+	}
+
+	return false
+}
+
+// IsLocalConst reports whether the name is bound as a constant in this scope, ignoring the outer scopes
+func (environment *Environment) IsLocalConst(name string) bool {
+	return environment.constants[name]
+}
+
+// Assign updates the binding of the name in the nearest scope defining it. It reports false without binding anything
+// if the name is not defined in any scope. Assign does not check for constants, see IsConst.
+func (environment *Environment) Assign(name string, value Object) (Object, bool) {
+	for env := environment; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			env.store[name] = value
+			return value, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,352 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/ast"
+	"github.com/jpiechowka/micron-language-interpreter-go/code"
+	"github.com/jpiechowka/micron-language-interpreter-go/token"
+	"strconv"
+	"strings"
+)
+
+type ObjectType string
+
+const (
+	INTEGER_OBJ           = "INTEGER"
+	FLOAT_OBJ             = "FLOAT"
+	BOOLEAN_OBJ           = "BOOLEAN"
+	STRING_OBJ            = "STRING"
+	NULL_OBJ              = "NULL"
+	RETURN_VALUE_OBJ      = "RETURN_VALUE"
+	ERROR_OBJ             = "ERROR"
+	FUNCTION_OBJ          = "FUNCTION"
+	ARRAY_OBJ             = "ARRAY"
+	HASH_OBJ              = "HASH"
+	RANGE_OBJ             = "RANGE"
+	BREAK_OBJ             = "BREAK"
+	CONTINUE_OBJ          = "CONTINUE"
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+)
+
+type Object interface {
+	Type() ObjectType
+	Inspect() string
+}
+
+// HashKey identifies the value of a hashable object. Equal values produce equal keys, so e.g. two different
+// string objects with the same content address the same entry of a hash. Strings keep their content in Text
+// instead of a digest, so keys of different strings never collide.
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+	Text  string
+}
+
+// Hashable is implemented by the objects which can be used as keys of a hash
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+type Integer struct {
+	Value int64
+}
+
+func (integer *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (integer *Integer) Inspect() string  { return fmt.Sprintf("%d", integer.Value) }
+func (integer *Integer) HashKey() HashKey {
+	return HashKey{Type: integer.Type(), Value: uint64(integer.Value)}
+}
+
+type Float struct {
+	Value float64
+}
+
+func (float *Float) Type() ObjectType { return FLOAT_OBJ }
+
+// Inspect always includes a decimal point or an exponent, so floats are distinguishable from integers
+func (float *Float) Inspect() string {
+	formatted := strconv.FormatFloat(float.Value, 'g', -1, 64)
+
+	if strings.ContainsAny(formatted, ".eIN") {
+		return formatted
+	}
+
+	return formatted + ".0"
+}
+
+type Boolean struct {
+	Value bool
+}
+
+func (boolean *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
+func (boolean *Boolean) Inspect() string  { return fmt.Sprintf("%t", boolean.Value) }
+func (boolean *Boolean) HashKey() HashKey {
+	var value uint64
+	if boolean.Value {
+		value = 1
+	}
+	return HashKey{Type: boolean.Type(), Value: value}
+}
+
+type String struct {
+	Value string
+}
+
+func (str *String) Type() ObjectType { return STRING_OBJ }
+func (str *String) Inspect() string  { return str.Value }
+func (str *String) HashKey() HashKey { return HashKey{Type: str.Type(), Text: str.Value} }
+
+type Null struct{}
+
+func (null *Null) Type() ObjectType { return NULL_OBJ }
+func (null *Null) Inspect() string  { return "null" }
+
+// ReturnValue wraps the value of a return statement so the evaluator can stop executing
+// further statements and unwrap it at the function or program boundary.
+type ReturnValue struct {
+	Value Object
+}
+
+func (returnValue *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+func (returnValue *ReturnValue) Inspect() string  { return returnValue.Value.Inspect() }
+
+// Break and Continue are produced by the loop control statements. Like ReturnValue, they make the evaluator skip
+// the rest of the block they appear in, until they reach the enclosing loop.
+type Break struct{}
+
+func (breakSignal *Break) Type() ObjectType { return BREAK_OBJ }
+func (breakSignal *Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (continueSignal *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (continueSignal *Continue) Inspect() string  { return "continue" }
+
+// Error is a runtime error. Pos and End locate the code which caused it, if it is known.
+type Error struct {
+	Message string
+	Pos     token.Position
+	End     token.Position
+}
+
+func (err *Error) Type() ObjectType { return ERROR_OBJ }
+
+// Error makes errors usable as Go errors, the virtual machine returns them from Run keeping their positions
+func (err *Error) Error() string { return err.Message }
+
+func (err *Error) Inspect() string {
+	if err.Pos.IsValid() {
+		return "ERROR: " + err.Pos.String() + ": " + err.Message
+	}
+
+	return "ERROR: " + err.Message
+}
+
+// Function is a function value. It keeps the environment it was defined in, so it can
+// resolve names from the enclosing scopes when it is called.
+type Function struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (function *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (function *Function) Inspect() string {
+	return inspectFunction(function.Parameters, function.Body)
+}
+
+// inspectFunction prints the source of a function, shared by the functions of the evaluator and the virtual machine
+func inspectFunction(parameters []*ast.Identifier, body *ast.BlockStatement) string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, param := range parameters {
+		params = append(params, param.String())
+	}
+
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
+// CompiledFunction is the bytecode of a function literal, it is stored in the constant pool of the compiled program
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	NumParameters int
+	// Scopes lists the variable names of the scopes in the function by slot. The first scope is the one of the
+	// function itself and starts with the parameters, the others are loop bodies entered with code.OpEnterScope.
+	Scopes [][]string
+	// Calls holds the call expressions of the function by the position of their code.OpCall instruction, so errors
+	// of calls are located at the same nodes as in the evaluator
+	Calls map[int]*ast.CallExpression
+	// Literal is the source of the function, nil for the compiled program itself
+	Literal *ast.FunctionLiteral
+}
+
+func (compiledFunction *CompiledFunction) Type() ObjectType { return COMPILED_FUNCTION_OBJ }
+func (compiledFunction *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", compiledFunction)
+}
+
+// Closure is a compiled function together with the scope it was created in, which the virtual machine uses to
+// resolve the variables of the enclosing functions and loop bodies when the closure is called. To programs it is
+// just a function, so it has the same type as a Function of the evaluator.
+type Closure struct {
+	Fn    *CompiledFunction
+	Scope *Scope
+}
+
+func (closure *Closure) Type() ObjectType { return FUNCTION_OBJ }
+func (closure *Closure) Inspect() string {
+	if closure.Fn.Literal == nil {
+		return fmt.Sprintf("Closure[%p]", closure)
+	}
+
+	return inspectFunction(closure.Fn.Literal.Parameters, closure.Fn.Literal.Body)
+}
+
+type Array struct {
+	Elements []Object
+	Frozen   bool // frozen arrays cannot be modified, see Freeze
+}
+
+func (array *Array) Type() ObjectType { return ARRAY_OBJ }
+func (array *Array) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, element := range array.Elements {
+		elements = append(elements, element.Inspect())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash maps hashable keys to values. It remembers the order in which the keys were first inserted: iterating over
+// a hash and inspecting it always follow that order. Overwriting the value of an existing key keeps its position.
+type Hash struct {
+	pairs  map[HashKey]HashPair
+	keys   []HashKey // in insertion order
+	Frozen bool      // frozen hashes cannot be modified, see Freeze
+}
+
+func NewHash() *Hash {
+	return &Hash{pairs: make(map[HashKey]HashPair)}
+}
+
+func (hash *Hash) Type() ObjectType { return HASH_OBJ }
+func (hash *Hash) Inspect() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hash.Pairs() {
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Get returns the pair stored under the key, if there is one
+func (hash *Hash) Get(key Hashable) (HashPair, bool) {
+	pair, ok := hash.pairs[key.HashKey()]
+	return pair, ok
+}
+
+// Set stores the value under the key, replacing the previous value if the key is already present
+func (hash *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+
+	if _, ok := hash.pairs[hashKey]; !ok {
+		hash.keys = append(hash.keys, hashKey)
+	}
+
+	hash.pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+// Len returns the number of pairs in the hash
+func (hash *Hash) Len() int {
+	return len(hash.keys)
+}
+
+// Pairs returns the pairs of the hash in insertion order
+func (hash *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, len(hash.keys))
+
+	for _, key := range hash.keys {
+		pairs = append(pairs, hash.pairs[key])
+	}
+
+	return pairs
+}
+
+// Range is the sequence of integers from Start up to, but not including, End
+type Range struct {
+	Start int64
+	End   int64
+}
+
+func (rangeObject *Range) Type() ObjectType { return RANGE_OBJ }
+func (rangeObject *Range) Inspect() string {
+	return fmt.Sprintf("%d..%d", rangeObject.Start, rangeObject.End)
+}
+
+// Freeze returns an immutable copy of arrays and hashes, including copies of the arrays and hashes they contain.
+// The original stays mutable, so other bindings of it are not affected. Frozen values and other objects are
+// immutable already and are returned unchanged.
+func Freeze(obj Object) Object {
+	return freeze(obj, make(map[Object]Object))
+}
+
+// freeze copies the object, reusing the copies made so far, so shared and cyclic values keep their shape
+func freeze(obj Object, copies map[Object]Object) Object {
+	if frozen, ok := copies[obj]; ok {
+		return frozen
+	}
+
+	switch obj := obj.(type) {
+	case *Array:
+		if obj.Frozen {
+			return obj
+		}
+		frozen := &Array{Elements: make([]Object, len(obj.Elements)), Frozen: true}
+		copies[obj] = frozen
+		for i, element := range obj.Elements {
+			frozen.Elements[i] = freeze(element, copies)
+		}
+		return frozen
+	case *Hash:
+		if obj.Frozen {
+			return obj
+		}
+		frozen := NewHash()
+		frozen.Frozen = true
+		copies[obj] = frozen
+		for _, key := range obj.keys {
+			pair := obj.pairs[key]
+			frozen.keys = append(frozen.keys, key)
+			frozen.pairs[key] = HashPair{Key: pair.Key, Value: freeze(pair.Value, copies)}
+		}
+		return frozen
+	}
+
+	return obj
+}
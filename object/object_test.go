@@ -0,0 +1,285 @@
+package object
+
+import (
+	"github.com/jpiechowka/micron-language-interpreter-go/ast"
+	"github.com/jpiechowka/micron-language-interpreter-go/token"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	param := &ast.Identifier{Token: token.Token{TokenType: token.IDENT, Literal: "x"}, Value: "x"}
+
+	tests := []struct {
+		obj          Object
+		expectedType ObjectType
+		expected     string
+	}{
+		{&Integer{Value: -1337}, INTEGER_OBJ, "-1337"},
+		{&Float{Value: 1.5}, FLOAT_OBJ, "1.5"},
+		{&Float{Value: 2}, FLOAT_OBJ, "2.0"},
+		{&Float{Value: 1e21}, FLOAT_OBJ, "1e+21"},
+		{&Boolean{Value: true}, BOOLEAN_OBJ, "true"},
+		{&String{Value: "foo bar"}, STRING_OBJ, "foo bar"},
+		{&Null{}, NULL_OBJ, "null"},
+		{&ReturnValue{Value: &Integer{Value: 5}}, RETURN_VALUE_OBJ, "5"},
+		{&Error{Message: "Identifier not found: x"}, ERROR_OBJ, "ERROR: Identifier not found: x"},
+		{
+			&Error{Message: "Not a function: INTEGER", Pos: token.Position{Offset: 4, Line: 2, Column: 3}},
+			ERROR_OBJ,
+			"ERROR: 2:3: Not a function: INTEGER",
+		},
+		{&Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "two"}}}, ARRAY_OBJ, "[1, two]"},
+		{&Array{Elements: []Object{}}, ARRAY_OBJ, "[]"},
+		{NewHash(), HASH_OBJ, "{}"},
+		{
+			&Function{
+				Parameters: []*ast.Identifier{param, param},
+				Body:       &ast.BlockStatement{Statements: []ast.Statement{&ast.ExpressionStatement{Expression: param}}},
+			},
+			FUNCTION_OBJ,
+			"fn(x, x) {\nx\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.obj.Type() != tt.expectedType {
+			t.Errorf("Object has wrong type. Expected %s, got %s instead", tt.expectedType, tt.obj.Type())
+		}
+
+		if tt.obj.Inspect() != tt.expected {
+			t.Errorf("Object.Inspect() is wrong. Expected %q, got %q instead", tt.expected, tt.obj.Inspect())
+		}
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("a", &Integer{Value: 1})
+	outer.Set("b", &Integer{Value: 2})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("b", &Integer{Value: 20})
+	inner.Set("c", &Integer{Value: 30})
+
+	tests := []struct {
+		env      *Environment
+		name     string
+		expected int64
+		found    bool
+	}{
+		{outer, "a", 1, true},
+		{outer, "b", 2, true},
+		{outer, "c", 0, false},
+		{inner, "a", 1, true},
+		{inner, "b", 20, true},
+		{inner, "c", 30, true},
+		{inner, "d", 0, false},
+	}
+
+	for _, tt := range tests {
+		value, ok := tt.env.Get(tt.name)
+		if ok != tt.found {
+			t.Errorf("Lookup of %q returned found=%t, expected %t", tt.name, ok, tt.found)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		integer, isInteger := value.(*Integer)
+		if !isInteger {
+			t.Errorf("Value of %q is not Integer. Got %T instead", tt.name, value)
+			continue
+		}
+
+		if integer.Value != tt.expected {
+			t.Errorf("Value of %q is wrong. Expected %d, got %d instead", tt.name, tt.expected, integer.Value)
+		}
+	}
+}
+
+func TestEnvironmentAssign(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("a", &Integer{Value: 1})
+	outer.Set("b", &Integer{Value: 2})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("b", &Integer{Value: 20})
+
+	if _, ok := inner.Assign("a", &Integer{Value: 10}); !ok {
+		t.Fatalf("Assignment to a name defined in the outer scope failed")
+	}
+
+	if _, ok := inner.Assign("b", &Integer{Value: 200}); !ok {
+		t.Fatalf("Assignment to a name defined in the inner scope failed")
+	}
+
+	if _, ok := inner.Assign("c", &Integer{Value: 30}); ok {
+		t.Errorf("Assignment to an undefined name succeeded")
+	}
+
+	tests := []struct {
+		env      *Environment
+		name     string
+		expected string
+	}{
+		{outer, "a", "10"}, // updated in the defining scope
+		{outer, "b", "2"},  // shadowed, so the inner binding was updated
+		{inner, "b", "200"},
+	}
+
+	for _, tt := range tests {
+		value, ok := tt.env.Get(tt.name)
+		if !ok || value.Inspect() != tt.expected {
+			t.Errorf("Value of %q is wrong. Expected %s, got %v instead", tt.name, tt.expected, value)
+		}
+	}
+
+	if _, ok := inner.Get("c"); ok {
+		t.Errorf("Failed assignment created a binding")
+	}
+}
+
+func TestEnvironmentConstants(t *testing.T) {
+	outer := NewEnvironment()
+	outer.SetConst("c", &Integer{Value: 1})
+	outer.Set("v", &Integer{Value: 2})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("c", &Integer{Value: 3}) // Shadows the constant
+
+	if !outer.IsConst("c") || outer.IsConst("v") || outer.IsConst("missing") {
+		t.Errorf("outer.IsConst() is wrong")
+	}
+
+	if inner.IsConst("c") {
+		t.Errorf("inner.IsConst() reports the shadowed constant")
+	}
+
+	if !NewEnclosedEnvironment(outer).IsConst("c") {
+		t.Errorf("IsConst() does not resolve constants of outer scopes")
+	}
+
+	if !outer.IsLocalConst("c") || NewEnclosedEnvironment(outer).IsLocalConst("c") {
+		t.Errorf("IsLocalConst() is wrong")
+	}
+
+	outer.Set("c", &Integer{Value: 4})
+	if outer.IsConst("c") {
+		t.Errorf("Set() did not replace the constant binding")
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	inner := &Array{Elements: []Object{&Integer{Value: 1}}}
+	hash := NewHash()
+	hash.Set(&String{Value: "inner"}, inner)
+	outer := &Array{Elements: []Object{hash, &Integer{Value: 2}}}
+	inner.Elements = append(inner.Elements, outer) // A cycle must not prevent freezing
+
+	frozen, ok := Freeze(outer).(*Array)
+	if !ok || frozen == outer {
+		t.Fatalf("Freeze() did not return a copy of the array. Got %T (%+v) instead", frozen, frozen)
+	}
+
+	if outer.Frozen || hash.Frozen || inner.Frozen {
+		t.Errorf("Freeze() modified the original values. Got outer=%t, hash=%t, inner=%t", outer.Frozen, hash.Frozen, inner.Frozen)
+	}
+
+	frozenHash := frozen.Elements[0].(*Hash)
+	pair, _ := frozenHash.Get(&String{Value: "inner"})
+	frozenInner := pair.Value.(*Array)
+
+	if !frozen.Frozen || !frozenHash.Frozen || !frozenInner.Frozen {
+		t.Errorf("Freeze() did not freeze all nested values. Got outer=%t, hash=%t, inner=%t", frozen.Frozen, frozenHash.Frozen, frozenInner.Frozen)
+	}
+
+	if frozenHash == hash || frozenInner == inner {
+		t.Errorf("Freeze() did not copy the nested values")
+	}
+
+	if frozenInner.Elements[1] != frozen {
+		t.Errorf("Freeze() did not keep the cycle. Got %T instead", frozenInner.Elements[1])
+	}
+
+	if Freeze(frozen) != frozen {
+		t.Errorf("Freeze() did not return a frozen value unchanged")
+	}
+
+	integer := &Integer{Value: 5}
+	if Freeze(integer) != integer {
+		t.Errorf("Freeze() did not return an immutable value unchanged")
+	}
+}
+
+func TestHashKey(t *testing.T) {
+	hello1 := &String{Value: "Hello World"}
+	hello2 := &String{Value: "Hello World"}
+	diff := &String{Value: "My name is johnny"}
+
+	if hello1.HashKey() != hello2.HashKey() {
+		t.Errorf("Strings with same content have different hash keys")
+	}
+
+	if hello1.HashKey() == diff.HashKey() {
+		t.Errorf("Strings with different content have same hash keys")
+	}
+
+	if (&Integer{Value: 1}).HashKey() != (&Integer{Value: 1}).HashKey() {
+		t.Errorf("Integers with same value have different hash keys")
+	}
+
+	if (&Integer{Value: 1}).HashKey() == (&Boolean{Value: true}).HashKey() {
+		t.Errorf("Integer 1 and true have same hash keys")
+	}
+
+	if (&Integer{Value: 1}).HashKey() == (&String{Value: "1"}).HashKey() {
+		t.Errorf("Integer 1 and string \"1\" have same hash keys")
+	}
+
+	if (&Boolean{Value: false}).HashKey() == (&Boolean{Value: true}).HashKey() {
+		t.Errorf("true and false have same hash keys")
+	}
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	hash := NewHash()
+
+	hash.Set(&String{Value: "c"}, &Integer{Value: 1})
+	hash.Set(&Integer{Value: 5}, &Integer{Value: 2})
+	hash.Set(&Boolean{Value: true}, &Integer{Value: 3})
+	hash.Set(&String{Value: "a"}, &Integer{Value: 4})
+	hash.Set(&String{Value: "c"}, &Integer{Value: 5}) // Overwriting keeps the position of the key
+
+	expected := "{c: 5, 5: 2, true: 3, a: 4}"
+	if hash.Inspect() != expected {
+		t.Errorf("hash.Inspect() is wrong. Expected %q, got %q instead", expected, hash.Inspect())
+	}
+
+	if hash.Len() != 4 {
+		t.Errorf("hash.Len() is wrong. Expected 4, got %d instead", hash.Len())
+	}
+
+	pair, ok := hash.Get(&String{Value: "c"})
+	if !ok || pair.Value.Inspect() != "5" {
+		t.Errorf("hash.Get() returned a wrong pair. Got %+v (%t) instead", pair, ok)
+	}
+
+	if _, ok := hash.Get(&String{Value: "missing"}); ok {
+		t.Errorf("hash.Get() found a missing key")
+	}
+}
+
+func TestScope(t *testing.T) {
+	outer := NewScope([]string{"a", "b"}, nil)
+	inner := NewScope([]string{"c"}, outer)
+
+	if len(outer.Slots) != 2 || len(inner.Slots) != 1 {
+		t.Errorf("NewScope() did not allocate a slot per name. Got %d and %d instead", len(outer.Slots), len(inner.Slots))
+	}
+
+	if inner.Up(0) != inner || inner.Up(1) != outer || inner.Up(2) != nil {
+		t.Errorf("Up() returned a wrong scope")
+	}
+}
@@ -0,0 +1,22 @@
+package object
+
+// Scope holds the variables of a function call or of a loop iteration in the virtual machine. Unlike an Environment
+// it is addressed by slot, as the compiler resolves every name to a slot in one of the enclosing scopes. Slots of
+// variables which are not defined yet are nil.
+type Scope struct {
+	Slots []Object
+	Names []string // names of the slots, used in error messages
+	Outer *Scope
+}
+
+func NewScope(names []string, outer *Scope) *Scope {
+	return &Scope{Slots: make([]Object, len(names)), Names: names, Outer: outer}
+}
+
+// Up returns the scope depth levels out of this one
+func (scope *Scope) Up(depth int) *Scope {
+	for ; depth > 0; depth-- {
+		scope = scope.Outer
+	}
+	return scope
+}
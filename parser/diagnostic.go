@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	ERROR Severity = iota
+	WARNING
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case ERROR:
+		return "error"
+	case WARNING:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrorCode identifies the kind of a diagnostic, so it can be looked up or matched on without parsing messages.
+// Codes of errors found by the lexer are defined in the lexer package.
+type ErrorCode string
+
+const (
+	UNEXPECTED_TOKEN      ErrorCode = "E0001"
+	MISSING_EXPRESSION    ErrorCode = "E0002"
+	ILLEGAL_CHARACTER     ErrorCode = "E0003"
+	INVALID_NUMBER        ErrorCode = "E0004"
+	UNTERMINATED_BLOCK    ErrorCode = "E0005"
+	OUTSIDE_LOOP          ErrorCode = "E0006"
+	INVALID_ASSIGNMENT    ErrorCode = "E0007"
+	CONSTANT_REASSIGNMENT ErrorCode = "E0008"
+)
+
+// Diagnostic is a single problem found in the source code, spanning from Pos (inclusive) to End (exclusive).
+type Diagnostic struct {
+	Severity Severity
+	Code     ErrorCode
+	Pos      token.Position
+	End      token.Position
+	Message  string
+	Hints    []string
+}
+
+func (diagnostic *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", diagnostic.Pos, diagnostic.Severity, diagnostic.Code, diagnostic.Message)
+}
+
+// Render formats the diagnostic together with the offending source line and a caret underline, e.g.
+//
+//	error[E0001]: Expected next token to be =, got INT instead
+//	 --> 1:7
+//	  |
+//	1 | let x 5;
+//	  |       ^
+//	  = hint: ...
+func (diagnostic *Diagnostic) Render(source string) string {
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "%s[%s]: %s\n", diagnostic.Severity, diagnostic.Code, diagnostic.Message)
+
+	if !diagnostic.Pos.IsValid() || diagnostic.Pos.Offset > len(source) {
+		for _, hint := range diagnostic.Hints {
+			fmt.Fprintf(&out, "  = hint: %s\n", hint)
+		}
+		return out.String()
+	}
+
+	lineStart := strings.LastIndexByte(source[:diagnostic.Pos.Offset], '\n') + 1
+	lineEnd := strings.IndexByte(source[diagnostic.Pos.Offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(source)
+	} else {
+		lineEnd += diagnostic.Pos.Offset
+	}
+
+	// Spans covering multiple lines are underlined only up to the end of the first line
+	underlineEnd := diagnostic.End.Offset
+	if underlineEnd > lineEnd {
+		underlineEnd = lineEnd
+	}
+
+	underlineLength := 1
+	if underlineEnd > diagnostic.Pos.Offset {
+		underlineLength = utf8.RuneCountInString(source[diagnostic.Pos.Offset:underlineEnd])
+	}
+
+	lineNumber := strconv.Itoa(diagnostic.Pos.Line)
+	gutter := strings.Repeat(" ", len(lineNumber))
+
+	fmt.Fprintf(&out, "%s--> %s\n", gutter, diagnostic.Pos)
+	fmt.Fprintf(&out, "%s |\n", gutter)
+	fmt.Fprintf(&out, "%s | %s\n", lineNumber, strings.TrimRight(source[lineStart:lineEnd], "\r"))
+	fmt.Fprintf(&out, "%s | ", gutter)
+
+	// Tabs are kept so the caret lines up with the source line no matter how the terminal renders them
+	for _, char := range source[lineStart:diagnostic.Pos.Offset] {
+		if char == '\t' {
+			out.WriteRune('\t')
+		} else {
+			out.WriteRune(' ')
+		}
+	}
+
+	out.WriteString(strings.Repeat("^", underlineLength))
+	out.WriteString("\n")
+
+	for _, hint := range diagnostic.Hints {
+		fmt.Fprintf(&out, "%s = hint: %s\n", gutter, hint)
+	}
+
+	return out.String()
+}
+
+// ErrorList is a list of diagnostics which can be used as a single error value.
+type ErrorList []*Diagnostic
+
+func (list ErrorList) Len() int      { return len(list) }
+func (list ErrorList) Swap(i, j int) { list[i], list[j] = list[j], list[i] }
+func (list ErrorList) Less(i, j int) bool {
+	return list[i].Pos.Offset < list[j].Pos.Offset
+}
+
+// Sort orders the diagnostics by their position in the source code. Diagnostics reported at the same position keep their order.
+func (list ErrorList) Sort() {
+	sort.Stable(list)
+}
+
+func (list ErrorList) Error() string {
+	switch len(list) {
+	case 0:
+		return "no errors"
+	case 1:
+		return list[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", list[0], len(list)-1)
+	}
+}
+
+// Err returns nil if the list is empty and the list itself otherwise, so it can be returned as an error without
+// ending up with a non-nil error interface holding an empty list.
+func (list ErrorList) Err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// Render formats every diagnostic in the list, see Diagnostic.Render.
+func (list ErrorList) Render(source string) string {
+	var out bytes.Buffer
+
+	for i, diagnostic := range list {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(diagnostic.Render(source))
+	}
+
+	return out.String()
+}
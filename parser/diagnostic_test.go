@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"github.com/jpiechowka/micron-language-interpreter-go/lexer"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedCode    ErrorCode
+		expectedMessage string
+		expectedPos     string
+	}{
+		{"let x 5;", UNEXPECTED_TOKEN, "Expected next token to be =, got INT instead", "1:7"},
+		{"let = 5;", UNEXPECTED_TOKEN, "Expected next token to be IDENT, got = instead", "1:5"},
+		{"5 + ;", MISSING_EXPRESSION, "No prefix parse function for ; found", "1:5"},
+		{"let x = 1 # 2;", ILLEGAL_CHARACTER, "Illegal character \"#\"", "1:11"},
+		{"let x = 99999999999999999999;", INVALID_NUMBER, "Integer literal 99999999999999999999 overflows int64", "1:9"},
+		{"0x1_0000_0000_0000_0000", INVALID_NUMBER, "Integer literal 0x1_0000_0000_0000_0000 overflows int64", "1:1"},
+		{"let x = 1__0;", lexer.MALFORMED_NUMBER, "Consecutive underscores in number literal", "1:10"},
+		{"if (x) {\n  x", UNTERMINATED_BLOCK, "Expected } to close the block, got EOF instead", "2:4"},
+		{"let s = \"abc", lexer.UNTERMINATED_STRING, "Unterminated string literal", "1:9"},
+		{"let s = \"a\\qc\";", lexer.INVALID_ESCAPE, "Invalid escape sequence \\q", "1:11"},
+		{"let ą\xff = 1;", lexer.INVALID_UTF8, "Invalid UTF-8 encoding (byte 0xFF)", "1:6"},
+		{"let x = 1; /* comment", lexer.UNTERMINATED_COMMENT, "Unterminated block comment", "1:12"},
+		{"1e400", INVALID_NUMBER, "Float literal 1e400 is out of range", "1:1"},
+		{"let x = 2e;", lexer.MALFORMED_NUMBER, "Exponent has no digits", "1:10"},
+		{"let x = 1; break;", OUTSIDE_LOOP, "Cannot use break outside of a loop", "1:12"},
+		{"while (true) { fn() { continue; } }", OUTSIDE_LOOP, "Cannot use continue outside of a loop", "1:23"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+
+		errors := parser.GetErrors()
+		if len(errors) == 0 {
+			t.Errorf("Parser did not report any errors for %q", tt.input)
+			continue
+		}
+
+		diagnostic := errors[0]
+
+		if diagnostic.Severity != ERROR {
+			t.Errorf("Diagnostic severity is not %s. Got %s instead", ERROR, diagnostic.Severity)
+		}
+
+		if diagnostic.Code != tt.expectedCode {
+			t.Errorf("Diagnostic code is not %s. Got %s instead", tt.expectedCode, diagnostic.Code)
+		}
+
+		if diagnostic.Message != tt.expectedMessage {
+			t.Errorf("Diagnostic message is not %q. Got %q instead", tt.expectedMessage, diagnostic.Message)
+		}
+
+		if diagnostic.Pos.String() != tt.expectedPos {
+			t.Errorf("Diagnostic position is not %s. Got %s instead", tt.expectedPos, diagnostic.Pos)
+		}
+	}
+}
+
+func TestErrorListIsSortedByPosition(t *testing.T) {
+	errors := ErrorList{
+		&Diagnostic{Code: UNEXPECTED_TOKEN, Message: "third"},
+		&Diagnostic{Code: UNEXPECTED_TOKEN, Message: "second"},
+		&Diagnostic{Code: UNEXPECTED_TOKEN, Message: "first"},
+	}
+	errors[0].Pos.Offset = 20
+	errors[1].Pos.Offset = 10
+	errors[2].Pos.Offset = 0
+
+	errors.Sort()
+
+	for i, expected := range []string{"first", "second", "third"} {
+		if errors[i].Message != expected {
+			t.Errorf("errors[%d] is not %q. Got %q instead", i, expected, errors[i].Message)
+		}
+	}
+
+	if (ErrorList{}).Err() != nil {
+		t.Errorf("Err() of an empty list is not nil")
+	}
+
+	if errors.Err() == nil {
+		t.Errorf("Err() of a non-empty list is nil")
+	}
+}
+
+func TestRenderDiagnostic(t *testing.T) {
+	input := "let a = 1;\n\tlet b 2;"
+
+	parser := New(lexer.New(input))
+	parser.ParseProgram()
+
+	expected := "error[E0001]: Expected next token to be =, got INT instead\n" +
+		" --> 2:8\n" +
+		"  |\n" +
+		"2 | \tlet b 2;\n" +
+		"  | \t      ^\n"
+
+	rendered := parser.GetErrors().Render(input)
+	if rendered != expected {
+		t.Errorf("Rendered diagnostic is wrong. Expected\n%s\ngot\n%s", expected, rendered)
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors int
+		expectedAst    string
+	}{
+		{"let x 5; let y = 10;", 1, "<bad statement>let y = 10;"},
+		{"let = 5 * 5 + 1; let y = 10; y", 1, "<bad statement>let y = 10;y"},
+		{"let x = 5 + ; let y = 10;", 1, "let x = (5 + <bad expression>);let y = 10;"},
+		{"let x = ; let y = ; let z = 1;", 2, "let x = <bad expression>;let y = <bad expression>;let z = 1;"},
+		{"add(1, , 3); x", 1, "add(1, <bad expression>, 3)x"},
+		{"let f = fn(a b) { a + b }; f(1, 2)", 1, "let f = <bad expression>;f(1, 2)"},
+		{"if (x) { let = 1; x } else { y }; z", 1, "ifx <bad statement>xelse yz"},
+		{"let f = fn() { 1 + }; f()", 1, "let f = fn() (1 + <bad expression>);f()"},
+		{"let x = (1 + 2; let y = 3", 1, "let x = <bad expression>;let y = 3;"},
+		{"return # 1; return 2", 1, "return <bad expression>;return 2;"},
+		{"x = = 3; y", 1, "(x = <bad expression>)y"},
+		{"let x = (1 + ; 5", 1, "let x = <bad expression>;5"},
+		{"{1: 2, 3}; y", 1, "<bad expression>y"},
+		{"if (x) { {1: 2, 3}; y } else { z }; w", 1, "ifx <bad expression>yelse zw"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+
+		if len(parser.GetErrors()) != tt.expectedErrors {
+			t.Errorf("Wrong number of errors for %q. Expected %d, got %d instead: %v", tt.input, tt.expectedErrors,
+				len(parser.GetErrors()), parser.GetErrors())
+		}
+
+		if program.String() != tt.expectedAst {
+			t.Errorf("Partial AST for %q is wrong. Expected %q, got %q instead", tt.input, tt.expectedAst, program.String())
+		}
+	}
+}
@@ -11,23 +11,54 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGNMENT    // = or +=
+	LOGICAL_OR    // ||
+	LOGICAL_AND   // &&
 	EQUALS        // ==
 	LESSORGREATER // > or <
-	SUM           // +
-	PRODUCT       // *
+	RANGE         // 0..10
+	SUM           // + or |
+	PRODUCT       // * or <<
 	PREFIX        // -X or !X
+	POWER         // **
 	CALL          // myFunction(X)
+	INDEX         // array[index]
 )
 
 var precedences = map[token.TokenType]int{
-	token.EQUALITY:    EQUALS,
-	token.INEQUALITY:  EQUALS,
-	token.LESSTHAN:    LESSORGREATER,
-	token.GREATERTHAN: LESSORGREATER,
-	token.PLUS:        SUM,
-	token.MINUS:       SUM,
-	token.SLASH:       PRODUCT,
-	token.ASTERISK:    PRODUCT,
+	token.ASSIGN:               ASSIGNMENT,
+	token.PLUS_ASSIGN:          ASSIGNMENT,
+	token.MINUS_ASSIGN:         ASSIGNMENT,
+	token.ASTERISK_ASSIGN:      ASSIGNMENT,
+	token.SLASH_ASSIGN:         ASSIGNMENT,
+	token.PERCENT_ASSIGN:       ASSIGNMENT,
+	token.OR:                   LOGICAL_OR,
+	token.AND:                  LOGICAL_AND,
+	token.EQUALITY:             EQUALS,
+	token.INEQUALITY:           EQUALS,
+	token.LESSTHAN:             LESSORGREATER,
+	token.GREATERTHAN:          LESSORGREATER,
+	token.LESSTHAN_OR_EQUAL:    LESSORGREATER,
+	token.GREATERTHAN_OR_EQUAL: LESSORGREATER,
+	token.DOT_DOT:              RANGE,
+	token.PLUS:                 SUM,
+	token.MINUS:                SUM,
+	token.PIPE:                 SUM,
+	token.CARET:                SUM,
+	token.SLASH:                PRODUCT,
+	token.ASTERISK:             PRODUCT,
+	token.PERCENT:              PRODUCT,
+	token.AMPERSAND:            PRODUCT,
+	token.SHIFT_LEFT:           PRODUCT,
+	token.SHIFT_RIGHT:          PRODUCT,
+	token.POWER:                POWER,
+	token.LPAREN:               CALL,
+	token.LBRACKET:             INDEX,
+}
+
+// rightAssociative contains the infix operators which group from the right, e.g. 2 ** 3 ** 2 is 2 ** (3 ** 2)
+var rightAssociative = map[token.TokenType]bool{
+	token.POWER: true,
 }
 
 type (
@@ -37,18 +68,31 @@ type (
 
 type Parser struct {
 	lexer        *lexer.Lexer
-	errors       []string
+	errors       ErrorList
 	currentToken token.Token
 	peekToken    token.Token
 
 	prefixParseFunctions map[token.TokenType]prefixParseFunction
 	infixParseFunctions  map[token.TokenType]infixParseFunction
+
+	// lexerErrorCount is the number of lexer errors already converted into diagnostics
+	lexerErrorCount int
+
+	// needsSync is set when an error is reported and cleared once the parser skipped to a point where it can resume
+	needsSync bool
+
+	// loopDepth is the number of loops enclosing the current statement within the current function body
+	loopDepth int
+
+	// scope contains the declarations visible at the current token
+	scope *scope
 }
 
 func New(lexer *lexer.Lexer) *Parser {
 	parser := &Parser{
 		lexer:  lexer,
-		errors: []string{},
+		errors: ErrorList{},
+		scope:  newScope(nil),
 	}
 
 	// Prefix parsing
@@ -56,8 +100,16 @@ func New(lexer *lexer.Lexer) *Parser {
 
 	parser.registerPrefix(token.IDENT, parser.parseIdentifier)
 	parser.registerPrefix(token.INT, parser.parseIntegerLiteral)
+	parser.registerPrefix(token.FLOAT, parser.parseFloatLiteral)
+	parser.registerPrefix(token.STRING, parser.parseStringLiteral)
 	parser.registerPrefix(token.BANG, parser.parsePrefixExpression)
 	parser.registerPrefix(token.MINUS, parser.parsePrefixExpression)
+	parser.registerPrefix(token.TILDE, parser.parsePrefixExpression)
+	parser.registerPrefix(token.LPAREN, parser.parseGroupedExpression)
+	parser.registerPrefix(token.IF, parser.parseIfExpression)
+	parser.registerPrefix(token.FUNCTION, parser.parseFunctionLiteral)
+	parser.registerPrefix(token.LBRACKET, parser.parseArrayLiteral)
+	parser.registerPrefix(token.LBRACE, parser.parseHashLiteral)
 
 	//Infix parsing
 	parser.infixParseFunctions = make(map[token.TokenType]infixParseFunction)
@@ -66,10 +118,30 @@ func New(lexer *lexer.Lexer) *Parser {
 	parser.registerInfix(token.MINUS, parser.parseInfixExpression)
 	parser.registerInfix(token.SLASH, parser.parseInfixExpression)
 	parser.registerInfix(token.ASTERISK, parser.parseInfixExpression)
+	parser.registerInfix(token.PERCENT, parser.parseInfixExpression)
+	parser.registerInfix(token.POWER, parser.parseInfixExpression)
+	parser.registerInfix(token.AMPERSAND, parser.parseInfixExpression)
+	parser.registerInfix(token.PIPE, parser.parseInfixExpression)
+	parser.registerInfix(token.CARET, parser.parseInfixExpression)
+	parser.registerInfix(token.SHIFT_LEFT, parser.parseInfixExpression)
+	parser.registerInfix(token.SHIFT_RIGHT, parser.parseInfixExpression)
 	parser.registerInfix(token.EQUALITY, parser.parseInfixExpression)
 	parser.registerInfix(token.INEQUALITY, parser.parseInfixExpression)
 	parser.registerInfix(token.LESSTHAN, parser.parseInfixExpression)
 	parser.registerInfix(token.GREATERTHAN, parser.parseInfixExpression)
+	parser.registerInfix(token.LESSTHAN_OR_EQUAL, parser.parseInfixExpression)
+	parser.registerInfix(token.GREATERTHAN_OR_EQUAL, parser.parseInfixExpression)
+	parser.registerInfix(token.AND, parser.parseInfixExpression)
+	parser.registerInfix(token.OR, parser.parseInfixExpression)
+	parser.registerInfix(token.DOT_DOT, parser.parseInfixExpression)
+	parser.registerInfix(token.LPAREN, parser.parseCallExpression)
+	parser.registerInfix(token.LBRACKET, parser.parseIndexExpression)
+	parser.registerInfix(token.ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.PLUS_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.MINUS_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.ASTERISK_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.SLASH_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.PERCENT_ASSIGN, parser.parseAssignExpression)
 
 	// Boolean parsing
 	parser.registerPrefix(token.TRUE, parser.parseBoolean)
@@ -81,13 +153,49 @@ func New(lexer *lexer.Lexer) *Parser {
 	return parser
 }
 
-func (parser *Parser) GetErrors() []string {
+// GetErrors returns the diagnostics reported while parsing, sorted by their position in the source code.
+func (parser *Parser) GetErrors() ErrorList {
+	parser.errors.Sort()
 	return parser.errors
 }
 
+// reportError records a diagnostic, unless an error was reported since the last synchronization point. Anything
+// going wrong after the first error is most likely a consequence of it, like a missing closing parenthesis after an
+// incomplete expression, so only the first error of a statement is reported.
+func (parser *Parser) reportError(code ErrorCode, start token.Position, end token.Position, message string, hints ...string) {
+	if parser.needsSync {
+		return
+	}
+
+	diagnostic := &Diagnostic{Severity: ERROR, Code: code, Pos: start, End: end, Message: message, Hints: hints}
+	parser.errors = append(parser.errors, diagnostic)
+	parser.needsSync = true
+}
+
 func (parser *Parser) nextToken() {
 	parser.currentToken = parser.peekToken
 	parser.peekToken = parser.lexer.NextToken()
+
+	parser.collectLexerErrors()
+}
+
+// collectLexerErrors converts the errors found by the lexer into diagnostics. The lexer still produces well-formed
+// tokens in such cases, so there is no need to synchronize the parser.
+func (parser *Parser) collectLexerErrors() {
+	lexerErrors := parser.lexer.GetErrors()
+
+	for _, lexerError := range lexerErrors[parser.lexerErrorCount:] {
+		diagnostic := &Diagnostic{
+			Severity: ERROR,
+			Code:     ErrorCode(lexerError.Code),
+			Pos:      lexerError.Pos,
+			End:      lexerError.End,
+			Message:  lexerError.Message,
+		}
+		parser.errors = append(parser.errors, diagnostic)
+	}
+
+	parser.lexerErrorCount = len(lexerErrors)
 }
 
 func (parser *Parser) ParseProgram() *ast.Program {
@@ -95,10 +203,7 @@ func (parser *Parser) ParseProgram() *ast.Program {
 
 	for parser.currentToken.TokenType != token.EOF {
 		statement := parser.parseStatement()
-
-		if statement != nil {
-			program.Statements = append(program.Statements, statement)
-		}
+		program.Statements = append(program.Statements, statement)
 
 		parser.nextToken()
 	}
@@ -106,18 +211,81 @@ func (parser *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// parseStatement parses a single statement. If the statement contains syntax errors, the parser skips ahead to
+// the next synchronization point, so one mistake is reported once instead of cascading into unrelated errors.
+// Statements that could not be parsed at all are replaced by an ast.BadStatement, so the result is never nil.
 func (parser *Parser) parseStatement() ast.Statement {
+	startToken := parser.currentToken
+
+	var statement ast.Statement
+
 	switch parser.currentToken.TokenType {
-	case token.LET:
-		return parser.parseLetStatement()
+	case token.LET, token.CONST:
+		statement = parser.parseLetStatement()
 	case token.RETURN:
-		return parser.parseReturnStatement()
+		statement = parser.parseReturnStatement()
+	case token.WHILE:
+		statement = parser.parseWhileStatement()
+	case token.FOR:
+		statement = parser.parseForStatement()
+	case token.BREAK:
+		statement = parser.parseBreakStatement()
+	case token.CONTINUE:
+		statement = parser.parseContinueStatement()
 	default:
-		return parser.parseExpressionStatement()
+		statement = parser.parseExpressionStatement()
+	}
+
+	if parser.needsSync {
+		parser.synchronize()
+	}
+
+	if statement == nil {
+		return &ast.BadStatement{Token: startToken, From: startToken.Pos, To: parser.currentToken.End}
 	}
+
+	return statement
 }
 
-func (parser *Parser) parseLetStatement() *ast.LetStatement {
+// synchronize skips tokens until the next statement can be parsed. It stops after a semicolon, or before a keyword
+// starting a new statement or a closing brace of the enclosing block. Braces opened while skipping are skipped
+// together with their contents.
+func (parser *Parser) synchronize() {
+	parser.needsSync = false
+
+	if parser.isComparedTokenSameAsCurrent(token.SEMICOLON) {
+		return
+	}
+
+	depth := 0
+
+	for !parser.isComparedTokenSameAsPeek(token.EOF) {
+		switch parser.peekToken.TokenType {
+		case token.SEMICOLON:
+			if depth == 0 {
+				parser.nextToken()
+				return
+			}
+		case token.LET, token.CONST, token.RETURN, token.FUNCTION, token.WHILE, token.FOR, token.BREAK, token.CONTINUE:
+			if depth == 0 {
+				return
+			}
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
+
+		parser.nextToken()
+	}
+}
+
+// parseLetStatement returns ast.Statement instead of *ast.LetStatement, so a failed parse results in a nil
+// interface and not in an interface holding a nil pointer.
+func (parser *Parser) parseLetStatement() ast.Statement {
 	statement := &ast.LetStatement{Token: parser.currentToken}
 
 	if !parser.expectPeek(token.IDENT) {
@@ -130,27 +298,145 @@ func (parser *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
-	// TODO: We're skipping the expressions until we encounter a semicolon
-	for !parser.isComparedTokenSameAsCurrent(token.SEMICOLON) {
+	statement.Value = parser.parseNextExpression(LOWEST)
+
+	// The name is declared after its value, which still refers to the previous binding of the name, if any
+	parser.declare(statement.Name, statement.IsConst())
+
+	if parser.isComparedTokenSameAsPeek(token.SEMICOLON) {
 		parser.nextToken()
 	}
 
 	return statement
 }
 
-func (parser *Parser) parseReturnStatement() *ast.ReturnStatement {
+func (parser *Parser) parseReturnStatement() ast.Statement {
 	statement := &ast.ReturnStatement{Token: parser.currentToken}
 
-	parser.nextToken()
+	statement.ReturnValue = parser.parseNextExpression(LOWEST)
 
-	// TODO: We're skipping the expressions until we encounter a semicolon
-	for !parser.isComparedTokenSameAsCurrent(token.SEMICOLON) {
+	if parser.isComparedTokenSameAsPeek(token.SEMICOLON) {
 		parser.nextToken()
 	}
 
 	return statement
 }
 
+func (parser *Parser) parseWhileStatement() ast.Statement {
+	statement := &ast.WhileStatement{Token: parser.currentToken}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	statement.Condition = parser.parseNextExpression(LOWEST)
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = parser.parseLoopBody()
+
+	if parser.isComparedTokenSameAsPeek(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+func (parser *Parser) parseForStatement() ast.Statement {
+	statement := &ast.ForStatement{Token: parser.currentToken}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !parser.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	statement.Variable = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	if !parser.expectPeek(token.IN) {
+		return nil
+	}
+
+	statement.Iterable = parser.parseNextExpression(LOWEST)
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = parser.parseLoopBody(statement.Variable)
+
+	if parser.isComparedTokenSameAsPeek(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+// parseLoopBody parses the block of a loop, inside which break and continue statements are allowed. Every iteration
+// runs in a new scope, which contains the given loop variables.
+func (parser *Parser) parseLoopBody(variables ...*ast.Identifier) *ast.BlockStatement {
+	parser.openScope()
+	for _, variable := range variables {
+		parser.declare(variable, false)
+	}
+
+	parser.loopDepth++
+	body := parser.parseBlockStatement()
+	parser.loopDepth--
+
+	parser.closeScope()
+
+	return body
+}
+
+func (parser *Parser) parseBreakStatement() ast.Statement {
+	statement := &ast.BreakStatement{Token: parser.currentToken}
+
+	parser.checkInsideLoop()
+
+	if parser.isComparedTokenSameAsPeek(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+func (parser *Parser) parseContinueStatement() ast.Statement {
+	statement := &ast.ContinueStatement{Token: parser.currentToken}
+
+	parser.checkInsideLoop()
+
+	if parser.isComparedTokenSameAsPeek(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+// checkInsideLoop reports the current break or continue token if there is no loop it could refer to. A loop
+// outside of the enclosing function does not count, the statement cannot leave the function.
+func (parser *Parser) checkInsideLoop() {
+	if parser.loopDepth > 0 {
+		return
+	}
+
+	errorMsg := fmt.Sprintf("Cannot use %s outside of a loop", parser.currentToken.Literal)
+	parser.reportError(OUTSIDE_LOOP, parser.currentToken.Pos, parser.currentToken.End, errorMsg,
+		"break and continue can only appear in the body of a while or for loop")
+}
+
 func (parser *Parser) isComparedTokenSameAsCurrent(tokenToCompare token.TokenType) bool {
 	return parser.currentToken.TokenType == tokenToCompare
 }
@@ -169,9 +455,9 @@ func (parser *Parser) expectPeek(expectedToken token.TokenType) bool {
 	}
 }
 
-func (parser *Parser) peekError(token token.TokenType) {
-	errorMsg := fmt.Sprintf("Expected next token to be %s, got %s instead", token, parser.peekToken.TokenType)
-	parser.errors = append(parser.errors, errorMsg)
+func (parser *Parser) peekError(tokenType token.TokenType) {
+	errorMsg := fmt.Sprintf("Expected next token to be %s, got %s instead", tokenType, parser.peekToken.TokenType)
+	parser.reportError(UNEXPECTED_TOKEN, parser.peekToken.Pos, parser.peekToken.End, errorMsg)
 }
 
 func (parser *Parser) registerPrefix(tokenType token.TokenType, function prefixParseFunction) {
@@ -182,7 +468,7 @@ func (parser *Parser) registerInfix(tokenType token.TokenType, function infixPar
 	parser.infixParseFunctions[tokenType] = function
 }
 
-func (parser *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+func (parser *Parser) parseExpressionStatement() ast.Statement {
 	statement := &ast.ExpressionStatement{Token: parser.currentToken}
 
 	statement.Expression = parser.parseExpression(LOWEST)
@@ -195,16 +481,21 @@ func (parser *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 }
 
 func (parser *Parser) parseExpression(precedence int) ast.Expression {
+	startToken := parser.currentToken
 	prefix := parser.prefixParseFunctions[parser.currentToken.TokenType]
 
 	if prefix == nil {
-		parser.noPrefixParseFunctionError(parser.currentToken.TokenType)
-		return nil
+		parser.noPrefixParseFunctionError(parser.currentToken)
+		return &ast.BadExpression{Token: startToken, From: startToken.Pos, To: startToken.End}
 	}
 
 	leftExpression := prefix()
+	if leftExpression == nil {
+		leftExpression = &ast.BadExpression{Token: startToken, From: startToken.Pos, To: parser.currentToken.End}
+	}
 
-	for !parser.isComparedTokenSameAsPeek(token.SEMICOLON) && precedence < parser.peekPrecedence() {
+	// After an error the remaining tokens are left to synchronize, they would only produce follow-on errors
+	for !parser.needsSync && !parser.isComparedTokenSameAsPeek(token.SEMICOLON) && precedence < parser.peekPrecedence() {
 		infix := parser.infixParseFunctions[parser.peekToken.TokenType]
 
 		if infix == nil {
@@ -213,12 +504,30 @@ func (parser *Parser) parseExpression(precedence int) ast.Expression {
 
 		parser.nextToken()
 
-		leftExpression = infix(leftExpression)
+		left := leftExpression
+		leftExpression = infix(left)
+		if leftExpression == nil {
+			leftExpression = &ast.BadExpression{Token: startToken, From: left.Pos(), To: parser.currentToken.End}
+		}
 	}
 
 	return leftExpression
 }
 
+// parseNextExpression advances to the next token and parses the expression starting there. If the next token
+// cannot start an expression, it is reported and left unconsumed (e.g. a closing brace still ends its block)
+// and an ast.BadExpression is returned in place of the missing expression.
+func (parser *Parser) parseNextExpression(precedence int) ast.Expression {
+	if parser.prefixParseFunctions[parser.peekToken.TokenType] == nil {
+		parser.noPrefixParseFunctionError(parser.peekToken)
+		return &ast.BadExpression{Token: parser.peekToken, From: parser.peekToken.Pos, To: parser.peekToken.Pos}
+	}
+
+	parser.nextToken()
+
+	return parser.parseExpression(precedence)
+}
+
 func (parser *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
 }
@@ -229,8 +538,12 @@ func (parser *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(parser.currentToken.Literal, 0, 64)
 
 	if err != nil {
-		errorMsg := fmt.Sprintf("Could not parse %q as integer", parser.currentToken.Literal)
-		parser.errors = append(parser.errors, errorMsg)
+		// Malformed literals are already reported by the lexer, only values out of range need to be reported here
+		if numError, ok := err.(*strconv.NumError); ok && numError.Err == strconv.ErrRange {
+			errorMsg := fmt.Sprintf("Integer literal %s overflows int64", parser.currentToken.Literal)
+			parser.reportError(INVALID_NUMBER, parser.currentToken.Pos, parser.currentToken.End, errorMsg,
+				"Integers must be within the signed 64-bit range, from -9223372036854775808 to 9223372036854775807")
+		}
 		return nil
 	}
 
@@ -239,9 +552,39 @@ func (parser *Parser) parseIntegerLiteral() ast.Expression {
 	return literal
 }
 
-func (parser *Parser) noPrefixParseFunctionError(tokenType token.TokenType) {
-	errorMsg := fmt.Sprintf("No prefix parse function for %s found", tokenType)
-	parser.errors = append(parser.errors, errorMsg)
+func (parser *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{Token: parser.currentToken}
+
+	value, err := strconv.ParseFloat(parser.currentToken.Literal, 64)
+
+	if err != nil {
+		// Malformed literals are already reported by the lexer, only values out of range need to be reported here
+		if numError, ok := err.(*strconv.NumError); ok && numError.Err == strconv.ErrRange {
+			errorMsg := fmt.Sprintf("Float literal %s is out of range", parser.currentToken.Literal)
+			parser.reportError(INVALID_NUMBER, parser.currentToken.Pos, parser.currentToken.End, errorMsg)
+		}
+		return nil
+	}
+
+	literal.Value = value
+
+	return literal
+}
+
+func (parser *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: parser.currentToken, Value: parser.currentToken.Literal}
+}
+
+func (parser *Parser) noPrefixParseFunctionError(offendingToken token.Token) {
+	if offendingToken.TokenType == token.ILLEGAL {
+		errorMsg := fmt.Sprintf("Illegal character %q", offendingToken.Literal)
+		parser.reportError(ILLEGAL_CHARACTER, offendingToken.Pos, offendingToken.End, errorMsg)
+		return
+	}
+
+	errorMsg := fmt.Sprintf("No prefix parse function for %s found", offendingToken.TokenType)
+	parser.reportError(MISSING_EXPRESSION, offendingToken.Pos, offendingToken.End, errorMsg,
+		"An expression was expected here")
 }
 
 func (parser *Parser) parsePrefixExpression() ast.Expression {
@@ -250,9 +593,7 @@ func (parser *Parser) parsePrefixExpression() ast.Expression {
 		Operator: parser.currentToken.Literal,
 	}
 
-	parser.nextToken()
-
-	expression.Right = parser.parseExpression(PREFIX)
+	expression.Right = parser.parseNextExpression(PREFIX)
 
 	return expression
 }
@@ -282,9 +623,40 @@ func (parser *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 
 	precedence := parser.currentPrecedence()
 
-	parser.nextToken()
+	// Parsing the right side with a lower precedence lets an operator of the same kind bind to it first
+	if rightAssociative[parser.currentToken.TokenType] {
+		precedence--
+	}
+
+	expression.Right = parser.parseNextExpression(precedence)
+
+	return expression
+}
+
+// parseAssignExpression parses an assignment to the left expression, which must be an identifier or an index
+// expression. Assignments are right associative, so a = b = 1 assigns 1 to both a and b.
+func (parser *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    parser.currentToken,
+		Target:   target,
+		Operator: parser.currentToken.Literal,
+	}
+
+	switch target := target.(type) {
+	case *ast.Identifier:
+		parser.checkAssignable(target)
+	case *ast.IndexExpression:
+	case *ast.BadExpression:
+		// The target was already reported
+	default:
+		errorMsg := fmt.Sprintf("Cannot assign to %s", target.String())
+		parser.reportError(INVALID_ASSIGNMENT, target.Pos(), target.End(), errorMsg,
+			"Only variables and elements of arrays or hashes can be assigned to")
+	}
+
+	precedence := parser.currentPrecedence() - 1 // Right associative
 
-	expression.Right = parser.parseExpression(precedence)
+	expression.Value = parser.parseNextExpression(precedence)
 
 	return expression
 }
@@ -292,3 +664,273 @@ func (parser *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 func (parser *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: parser.currentToken, Value: parser.isComparedTokenSameAsCurrent(token.TRUE)}
 }
+
+func (parser *Parser) parseGroupedExpression() ast.Expression {
+	expression := parser.parseNextExpression(LOWEST)
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return expression
+}
+
+func (parser *Parser) parseIfExpression() ast.Expression {
+	expression := &ast.IfExpression{Token: parser.currentToken}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	expression.Condition = parser.parseNextExpression(LOWEST)
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Consequence = parser.parseBlockStatement()
+
+	if parser.isComparedTokenSameAsPeek(token.ELSE) {
+		parser.nextToken()
+
+		if !parser.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		expression.Alternative = parser.parseBlockStatement()
+	}
+
+	return expression
+}
+
+// parseBlockStatement parses statements until the closing brace. The current token must be the opening brace.
+func (parser *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: parser.currentToken, Statements: []ast.Statement{}}
+
+	parser.nextToken()
+
+	for !parser.isComparedTokenSameAsCurrent(token.RBRACE) && !parser.isComparedTokenSameAsCurrent(token.EOF) {
+		statement := parser.parseStatement()
+		block.Statements = append(block.Statements, statement)
+
+		parser.nextToken()
+	}
+
+	if parser.isComparedTokenSameAsCurrent(token.EOF) {
+		errorMsg := fmt.Sprintf("Expected %s to close the block, got %s instead", token.RBRACE, token.EOF)
+		hint := fmt.Sprintf("The block was opened at %s", block.Token.Pos)
+		parser.reportError(UNTERMINATED_BLOCK, parser.currentToken.Pos, parser.currentToken.End, errorMsg, hint)
+	}
+
+	block.Rbrace = parser.currentToken
+
+	return block
+}
+
+func (parser *Parser) parseFunctionLiteral() ast.Expression {
+	literal := &ast.FunctionLiteral{Token: parser.currentToken}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	literal.Parameters = parser.parseFunctionParameters()
+	if literal.Parameters == nil {
+		return nil
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	parser.openScope()
+	for _, parameter := range literal.Parameters {
+		parser.declare(parameter, false)
+	}
+
+	// The body of a function is not part of any loop the function is defined in
+	enclosingLoopDepth := parser.loopDepth
+	parser.loopDepth = 0
+	literal.Body = parser.parseBlockStatement()
+	parser.loopDepth = enclosingLoopDepth
+
+	parser.closeScope()
+
+	return literal
+}
+
+// parseFunctionParameters parses a comma separated list of identifiers. The current token must be the opening parenthesis.
+func (parser *Parser) parseFunctionParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+
+	if parser.isComparedTokenSameAsPeek(token.RPAREN) {
+		parser.nextToken()
+		return identifiers
+	}
+
+	if !parser.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	identifiers = append(identifiers, &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal})
+
+	for parser.isComparedTokenSameAsPeek(token.COMMA) {
+		parser.nextToken()
+
+		if !parser.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		identifiers = append(identifiers, &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal})
+	}
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return identifiers
+}
+
+func (parser *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	expression := &ast.CallExpression{Token: parser.currentToken, Function: function}
+
+	expression.Arguments = parser.parseExpressionList(token.RPAREN)
+	if expression.Arguments == nil {
+		return nil
+	}
+
+	expression.Rparen = parser.currentToken
+
+	return expression
+}
+
+// parseExpressionList parses a comma separated list of expressions closed by the end token. The current token
+// must be the one opening the list.
+func (parser *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if parser.isComparedTokenSameAsPeek(end) {
+		parser.nextToken()
+		return list
+	}
+
+	list = append(list, parser.parseNextExpression(LOWEST))
+
+	for parser.isComparedTokenSameAsPeek(token.COMMA) {
+		parser.nextToken()
+		list = append(list, parser.parseNextExpression(LOWEST))
+	}
+
+	if !parser.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
+func (parser *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: parser.currentToken}
+
+	array.Elements = parser.parseExpressionList(token.RBRACKET)
+	if array.Elements == nil {
+		return nil
+	}
+
+	array.Rbracket = parser.currentToken
+
+	return array
+}
+
+// parseIndexExpression parses both a[index] and the slice a[low:high], where either of the slice bounds can be
+// omitted. The current token is the opening bracket.
+func (parser *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	bracket := parser.currentToken
+
+	var index ast.Expression
+	if !parser.isComparedTokenSameAsPeek(token.COLON) {
+		index = parser.parseNextExpression(LOWEST)
+	}
+
+	if !parser.isComparedTokenSameAsPeek(token.COLON) {
+		if !parser.expectPeek(token.RBRACKET) {
+			return nil
+		}
+
+		return &ast.IndexExpression{Token: bracket, Left: left, Index: index, Rbracket: parser.currentToken}
+	}
+
+	parser.nextToken() // Move onto the colon
+
+	slice := &ast.SliceExpression{Token: bracket, Left: left, Low: index}
+
+	if !parser.isComparedTokenSameAsPeek(token.RBRACKET) {
+		slice.High = parser.parseNextExpression(LOWEST)
+	}
+
+	if !parser.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	slice.Rbracket = parser.currentToken
+
+	return slice
+}
+
+// parseHashLiteral parses {key: value, ...}. Blocks are only parsed where a statement list is expected, e.g. after
+// an if condition, so a brace which starts an expression always opens a hash literal.
+func (parser *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: parser.currentToken, Pairs: []ast.HashPair{}}
+
+	for !parser.isComparedTokenSameAsPeek(token.RBRACE) {
+		key := parser.parseNextExpression(LOWEST)
+
+		if !parser.expectPeek(token.COLON) {
+			parser.skipHashLiteral()
+			return nil
+		}
+
+		value := parser.parseNextExpression(LOWEST)
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if !parser.isComparedTokenSameAsPeek(token.RBRACE) && !parser.expectPeek(token.COMMA) {
+			parser.skipHashLiteral()
+			return nil
+		}
+	}
+
+	parser.nextToken()
+	hash.Rbrace = parser.currentToken
+
+	return hash
+}
+
+// skipHashLiteral skips the rest of a malformed hash literal including its closing brace. Otherwise synchronize()
+// would take the brace for the end of the enclosing block. It stops before a semicolon or EOF, in case the closing
+// brace is missing.
+func (parser *Parser) skipHashLiteral() {
+	depth := 0
+
+	for !parser.isComparedTokenSameAsPeek(token.EOF) {
+		switch parser.peekToken.TokenType {
+		case token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				parser.nextToken()
+				return
+			}
+			depth--
+		}
+
+		parser.nextToken()
+	}
+}
@@ -8,46 +8,86 @@ import (
 )
 
 func TestLetStatements(t *testing.T) {
-	input := `
-		let x = 5;
-		let y = 10;
-		let test_test = 13371337;
-`
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"let x = 5;", "x", 5},
+		{"let y = true;", "y", true},
+		{"let test_test = 13371337;", "test_test", 13371337},
+		{"let foobar = y;", "foobar", "y"},
+		{"let x = 5", "x", 5},
+	}
 
-	parser := New(lexer.New(input))
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
 
-	program := parser.ParseProgram()
-	checkParseErrors(t, parser)
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
 
-	if program == nil {
-		t.Fatalf("ParseProgram() retruned nil")
-	}
+		if program == nil {
+			t.Fatalf("ParseProgram() retruned nil")
+		}
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements length is not equal to 1. Actual length is: %d", len(program.Statements))
+		}
 
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements length is not equal to 3. Actual length is: %d", len(program.Statements))
+		statement := program.Statements[0]
+		if !testLetStatement(t, statement, tt.expectedIdentifier) {
+			return
+		}
+
+		value := statement.(*ast.LetStatement).Value
+		if !testLiteralExpression(t, value, tt.expectedValue) {
+			return
+		}
 	}
+}
 
+func TestReturnStatements(t *testing.T) {
 	tests := []struct {
-		expectedIdentifier string
+		input         string
+		expectedValue interface{}
 	}{
-		{"x"},
-		{"y"},
-		{"test_test"},
+		{"return 5;", 5},
+		{"return true;", true},
+		{"return 13371337;", 13371337},
+		{"return foobar;", "foobar"},
+		{"return 10", 10},
 	}
 
-	for i, tt := range tests {
-		statement := program.Statements[i]
-		if !testLetStatement(t, statement, tt.expectedIdentifier) {
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements length is not equal to 1. Actual length is: %d", len(program.Statements))
+		}
+
+		returnStatement, ok := program.Statements[0].(*ast.ReturnStatement)
+		if !ok {
+			t.Fatalf("returnStatement is not *ast.ReturnStatement. Got %T instead", program.Statements[0])
+		}
+
+		if returnStatement.TokenLiteral() != "return" {
+			t.Fatalf("returnStatement.TokenLiteral is not 'return'. Got %s instead", returnStatement.TokenLiteral())
+		}
+
+		if !testLiteralExpression(t, returnStatement.ReturnValue, tt.expectedValue) {
 			return
 		}
 	}
 }
 
-func TestReturnStatements(t *testing.T) {
+func TestStatementsWithoutSemicolons(t *testing.T) {
 	input := `
-		return 5;
-		return 10;
-		return 13371337;
+		let x = 5
+		let y = x * 2
+		return x + y
 `
 
 	parser := New(lexer.New(input))
@@ -55,21 +95,9 @@ func TestReturnStatements(t *testing.T) {
 	program := parser.ParseProgram()
 	checkParseErrors(t, parser)
 
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements length is not equal to 3. Actual length is: %d", len(program.Statements))
-	}
-
-	for _, statement := range program.Statements {
-		returnStatement, ok := statement.(*ast.ReturnStatement)
-
-		if !ok {
-			t.Errorf("returnStatement is not *ast.ReturnStatement. Got %T instead", statement)
-			continue
-		}
-
-		if returnStatement.TokenLiteral() != "return" {
-			t.Errorf("returnStatement.TokenLiteral is not 'return'. Got %s instead", returnStatement.TokenLiteral())
-		}
+	expected := "let x = 5;let y = (x * 2);return (x + y);"
+	if program.String() != expected {
+		t.Errorf("program.String() is not %q. Got %q instead", expected, program.String())
 	}
 }
 
@@ -135,6 +163,35 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"1e3;", 1000},
+		{"1.5e-3;", 0.0015},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+
+		literal, ok := statement.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("statement.Expression is not *ast.FloatLiteral. Got %T instead", statement.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value is not %g. Got %g instead", tt.expected, literal.Value)
+		}
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input        string
@@ -192,6 +249,10 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"77 < 1337;", 77, "<", 1337},
 		{"77 == 1337;", 77, "==", 1337},
 		{"77 != 1337;", 77, "!=", 1337},
+		{"77 <= 1337;", 77, "<=", 1337},
+		{"77 >= 1337;", 77, ">=", 1337},
+		{"77 && 1337;", 77, "&&", 1337},
+		{"77 || 1337;", 77, "||", 1337},
 	}
 
 	for _, infixTest := range infixTests {
@@ -281,6 +342,126 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"3 + 4 * 5 == 3 * 1 + 4 * 5",
 			"((3 + (4 * 5)) == ((3 * 1) + (4 * 5)))",
 		},
+		{
+			"1 + (2 + 3) + 4",
+			"((1 + (2 + 3)) + 4)",
+		},
+		{
+			"(5 + 5) * 2",
+			"((5 + 5) * 2)",
+		},
+		{
+			"2 / (5 + 5)",
+			"(2 / (5 + 5))",
+		},
+		{
+			"-(5 + 5)",
+			"(-(5 + 5))",
+		},
+		{
+			"!(true == true)",
+			"(!(true == true))",
+		},
+		{
+			"a + add(b * c) + d",
+			"((a + add((b * c))) + d)",
+		},
+		{
+			"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
+			"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
+		},
+		{
+			"add(a + b + c * d / f + g)",
+			"add((((a + b) + ((c * d) / f)) + g))",
+		},
+		{
+			"a // comment\n+ b /* comment */ * c",
+			"(a + (b * c))",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a < b && c >= d == true",
+			"((a < b) && ((c >= d) == true))",
+		},
+		{
+			"!a || -b <= c + 1",
+			"((!a) || ((-b) <= (c + 1)))",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"-2 ** 2",
+			"(-(2 ** 2))",
+		},
+		{
+			"a * b ** c % d",
+			"((a * (b ** c)) % d)",
+		},
+		{
+			"2 ** -1 ** 2",
+			"(2 ** (-(1 ** 2)))",
+		},
+		{
+			"a | b ^ c & d",
+			"((a | b) ^ (c & d))",
+		},
+		{
+			"a + b << c == d",
+			"((a + (b << c)) == d)",
+		},
+		{
+			"~a >> 1 & b",
+			"(((~a) >> 1) & b)",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"-a[0] ** 2",
+			"(-((a[0]) ** 2))",
+		},
+		{
+			"f(x)[0]",
+			"(f(x)[0])",
+		},
+		{
+			"a[1:][:2]",
+			"((a[1:])[:2])",
+		},
+		{
+			"x = y = 1 + 2",
+			"(x = (y = (1 + 2)))",
+		},
+		{
+			"x += a || b",
+			"(x += (a || b))",
+		},
+		{
+			"a[i + 1] *= f(x)[0]",
+			"((a[(i + 1)]) *= (f(x)[0]))",
+		},
+		{
+			"0..n + 1",
+			"(0 .. (n + 1))",
+		},
+		{
+			"a < 0..b",
+			"(a < (0 .. b))",
+		},
 	}
 
 	for _, precedenceTest := range tests {
@@ -297,6 +478,30 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 	}
 }
 
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello\tworld";`
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+
+	literal, ok := statement.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("statement.Expression is not *ast.StringLiteral. Got %T instead", statement.Expression)
+	}
+
+	if literal.Value != "hello\tworld" {
+		t.Errorf("literal.Value is not %q. Got %q instead", "hello\tworld", literal.Value)
+	}
+
+	if program.String() != input[:len(input)-1] {
+		t.Errorf("program.String() is not %s. Got %s instead", input[:len(input)-1], program.String())
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -327,6 +532,634 @@ func TestBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestIfExpression(t *testing.T) {
+	input := `if (x < y) { x }`
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. Got %d instead", 1, len(program.Statements))
+	}
+
+	statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. Got %T instead", program.Statements[0])
+	}
+
+	expression, ok := statement.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.IfExpression. Got %T instead", statement.Expression)
+	}
+
+	if !testInfixExpression(t, expression.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(expression.Consequence.Statements) != 1 {
+		t.Errorf("Consequence does not contain %d statements. Got %d instead", 1, len(expression.Consequence.Statements))
+	}
+
+	consequence, ok := expression.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Consequence.Statements[0] is not ast.ExpressionStatement. Got %T instead", expression.Consequence.Statements[0])
+	}
+
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if expression.Alternative != nil {
+		t.Errorf("expression.Alternative is not nil. Got %+v instead", expression.Alternative)
+	}
+}
+
+func TestIfElseExpression(t *testing.T) {
+	input := `if (x < y) { x } else { let z = y; z }`
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. Got %d instead", 1, len(program.Statements))
+	}
+
+	statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. Got %T instead", program.Statements[0])
+	}
+
+	expression, ok := statement.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.IfExpression. Got %T instead", statement.Expression)
+	}
+
+	if !testInfixExpression(t, expression.Condition, "x", "<", "y") {
+		return
+	}
+
+	if expression.Alternative == nil {
+		t.Fatalf("expression.Alternative is nil")
+	}
+
+	if len(expression.Alternative.Statements) != 2 {
+		t.Fatalf("Alternative does not contain %d statements. Got %d instead", 2, len(expression.Alternative.Statements))
+	}
+
+	if !testLetStatement(t, expression.Alternative.Statements[0], "z") {
+		return
+	}
+
+	alternative, ok := expression.Alternative.Statements[1].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Alternative.Statements[1] is not ast.ExpressionStatement. Got %T instead", expression.Alternative.Statements[1])
+	}
+
+	if !testIdentifier(t, alternative.Expression, "z") {
+		return
+	}
+}
+
+func TestUnterminatedBlock(t *testing.T) {
+	parser := New(lexer.New("if (x) { x"))
+	parser.ParseProgram()
+
+	if len(parser.GetErrors()) != 1 {
+		t.Fatalf("Parser should report exactly 1 error. Got %d instead: %v", len(parser.GetErrors()), parser.GetErrors())
+	}
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	input := `fn(x, y) { x + y; }`
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. Got %d instead", 1, len(program.Statements))
+	}
+
+	statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. Got %T instead", program.Statements[0])
+	}
+
+	function, ok := statement.Expression.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.FunctionLiteral. Got %T instead", statement.Expression)
+	}
+
+	if len(function.Parameters) != 2 {
+		t.Fatalf("Function literal parameters are wrong. Expected 2, got %d instead", len(function.Parameters))
+	}
+
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements does not contain 1 statement. Got %d instead", len(function.Body.Statements))
+	}
+
+	bodyStatement, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Function body statement is not ast.ExpressionStatement. Got %T instead", function.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStatement.Expression, "x", "+", "y")
+}
+
+func TestFunctionParameterParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+	}{
+		{input: "fn() {};", expectedParams: []string{}},
+		{input: "fn(x) {};", expectedParams: []string{"x"}},
+		{input: "fn(x, y, z) {};", expectedParams: []string{"x", "y", "z"}},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+		function := statement.Expression.(*ast.FunctionLiteral)
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("Length of parameters is wrong. Expected %d, got %d instead", len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, identifier := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], identifier)
+		}
+	}
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. Got %d instead", 1, len(program.Statements))
+	}
+
+	statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. Got %T instead", program.Statements[0])
+	}
+
+	expression, ok := statement.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.CallExpression. Got %T instead", statement.Expression)
+	}
+
+	if !testIdentifier(t, expression.Function, "add") {
+		return
+	}
+
+	if len(expression.Arguments) != 3 {
+		t.Fatalf("Wrong length of arguments. Expected 3, got %d instead", len(expression.Arguments))
+	}
+
+	testLiteralExpression(t, expression.Arguments[0], 1)
+	testInfixExpression(t, expression.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, expression.Arguments[2], 4, "+", 5)
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+
+	array, ok := statement.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.ArrayLiteral. Got %T instead", statement.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) is not 3. Got %d instead", len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestParsingEmptyArrayLiteral(t *testing.T) {
+	parser := New(lexer.New("[]"))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+
+	array, ok := statement.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.ArrayLiteral. Got %T instead", statement.Expression)
+	}
+
+	if len(array.Elements) != 0 {
+		t.Errorf("len(array.Elements) is not 0. Got %d instead", len(array.Elements))
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+
+	indexExpression, ok := statement.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.IndexExpression. Got %T instead", statement.Expression)
+	}
+
+	if !testIdentifier(t, indexExpression.Left, "myArray") {
+		return
+	}
+
+	testInfixExpression(t, indexExpression.Index, 1, "+", 1)
+
+	if indexExpression.Pos().Offset != 0 || indexExpression.End().Offset != len(input) {
+		t.Errorf("Wrong index expression span. Got %d-%d instead", indexExpression.Pos().Offset, indexExpression.End().Offset)
+	}
+}
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a[1:3]", "(a[1:3])"},
+		{"a[1:]", "(a[1:])"},
+		{"a[:3]", "(a[:3])"},
+		{"a[:]", "(a[:])"},
+		{"a[-2:x + 1]", "(a[(-2):(x + 1)])"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+
+		slice, ok := statement.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("statement.Expression is not ast.SliceExpression. Got %T instead", statement.Expression)
+		}
+
+		if !testIdentifier(t, slice.Left, "a") {
+			return
+		}
+
+		if slice.String() != tt.expected {
+			t.Errorf("slice.String() is not %q. Got %q instead", tt.expected, slice.String())
+		}
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"one": 1, "two": 2, "three": 3}`, `{"one": 1, "two": 2, "three": 3}`},
+		{`{}`, `{}`},
+		{`{"one": 0 + 1, "two": 10 - 8,}`, `{"one": (0 + 1), "two": (10 - 8)}`},
+		{`{1: true, true: "yes", x: [1]}`, `{1: true, true: "yes", x: [1]}`},
+		{`{"inner": {"a": 1}}["inner"]`, `({"inner": {"a": 1}}["inner"])`},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. Got %d instead", len(program.Statements))
+		}
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+
+		if statement.Expression.String() != tt.expected {
+			t.Errorf("Expression.String() is not %q. Got %q instead", tt.expected, statement.Expression.String())
+		}
+	}
+}
+
+func TestParsingHashLiteralPairs(t *testing.T) {
+	input := `{"b": 1, "a": 2 * 3}`
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+
+	hash, ok := statement.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.HashLiteral. Got %T instead", statement.Expression)
+	}
+
+	if len(hash.Pairs) != 2 {
+		t.Fatalf("hash.Pairs has wrong length. Got %d instead", len(hash.Pairs))
+	}
+
+	// The pairs keep the source order
+	if hash.Pairs[0].Key.String() != `"b"` || hash.Pairs[1].Key.String() != `"a"` {
+		t.Errorf("hash.Pairs are not in source order. Got %s instead", hash.String())
+	}
+
+	testIntegerLiteral(t, hash.Pairs[0].Value, 1)
+	testInfixExpression(t, hash.Pairs[1].Value, 2, "*", 3)
+
+	if hash.Pos().Offset != 0 || hash.End().Offset != len(input) {
+		t.Errorf("Wrong hash literal span. Got %d-%d instead", hash.Pos().Offset, hash.End().Offset)
+	}
+}
+
+func TestParsingMalformedHashLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`{"a" 1}`, "Expected next token to be :, got INT instead"},
+		{`{"a": 1 "b": 2}`, "Expected next token to be ,, got STRING instead"},
+		{`{"a": 1`, "Expected next token to be ,, got EOF instead"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+
+		errors := parser.GetErrors()
+		if len(errors) == 0 {
+			t.Errorf("Expected an error for %q", tt.input)
+			continue
+		}
+
+		if errors[0].Message != tt.expectedMessage {
+			t.Errorf("Wrong error message for %q. Expected %q, got %q instead", tt.input, tt.expectedMessage, errors[0].Message)
+		}
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { break; continue; }`
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. Got %d instead", len(program.Statements))
+	}
+
+	statement, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. Got %T instead", program.Statements[0])
+	}
+
+	if !testInfixExpression(t, statement.Condition, "x", "<", 10) {
+		return
+	}
+
+	if len(statement.Body.Statements) != 2 {
+		t.Fatalf("Body does not contain 2 statements. Got %d instead", len(statement.Body.Statements))
+	}
+
+	if _, ok := statement.Body.Statements[0].(*ast.BreakStatement); !ok {
+		t.Errorf("Body.Statements[0] is not ast.BreakStatement. Got %T instead", statement.Body.Statements[0])
+	}
+
+	if _, ok := statement.Body.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("Body.Statements[1] is not ast.ContinueStatement. Got %T instead", statement.Body.Statements[1])
+	}
+
+	if statement.End().Offset != len(input) {
+		t.Errorf("Wrong end of the while statement. Got %d instead", statement.End().Offset)
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	input := `for (element in [1, 2]) { element; }`
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	statement, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ForStatement. Got %T instead", program.Statements[0])
+	}
+
+	if !testIdentifier(t, statement.Variable, "element") {
+		return
+	}
+
+	if statement.Iterable.String() != "[1, 2]" {
+		t.Errorf("statement.Iterable is not %q. Got %q instead", "[1, 2]", statement.Iterable.String())
+	}
+
+	if len(statement.Body.Statements) != 1 {
+		t.Fatalf("Body does not contain 1 statement. Got %d instead", len(statement.Body.Statements))
+	}
+
+	if statement.String() != "for (element in [1, 2]) element" {
+		t.Errorf("statement.String() is wrong. Got %q instead", statement.String())
+	}
+}
+
+func TestLoopTrailingSemicolon(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedStatements int
+	}{
+		{"while (true) { break }; 1", 2},
+		{"for (x in y) { x }; 1", 2},
+		{"while (true) { break } 1", 2},
+		{"for (x in y) { continue; };", 1},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		if len(program.Statements) != tt.expectedStatements {
+			t.Errorf("Wrong number of statements for %q. Expected %d, got %d instead", tt.input, tt.expectedStatements, len(program.Statements))
+		}
+	}
+}
+
+func TestLoopControlOutsideOfLoop(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors int
+	}{
+		{"break;", 1},
+		{"continue", 1},
+		{"if (true) { break; }", 1},
+		{"while (true) { if (x) { break; } else { continue; } }", 0},
+		{"for (x in y) { while (true) { break; } continue; }", 0},
+		{"while (true) { let f = fn() { break; }; }", 1},
+		{"let f = fn() { while (true) { break; } }; break;", 1},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+
+		errors := parser.GetErrors()
+		if len(errors) != tt.expectedErrors {
+			t.Errorf("Wrong number of errors for %q. Expected %d, got %d instead: %v", tt.input, tt.expectedErrors, len(errors), errors)
+			continue
+		}
+
+		for _, diagnostic := range errors {
+			if diagnostic.Code != OUTSIDE_LOOP {
+				t.Errorf("Diagnostic code is not %s. Got %s instead", OUTSIDE_LOOP, diagnostic.Code)
+			}
+		}
+	}
+}
+
+func TestAssignExpression(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedTarget   string
+		expectedOperator string
+		expectedValue    string
+	}{
+		{"x = 5;", "x", "=", "5"},
+		{"x += y * 2;", "x", "+=", "(y * 2)"},
+		{`h["k"] = v;`, `(h["k"])`, "=", "v"},
+		{"a[0] -= 1", "(a[0])", "-=", "1"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+
+		assignment, ok := statement.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("statement.Expression is not ast.AssignExpression. Got %T instead", statement.Expression)
+		}
+
+		if assignment.Target.String() != tt.expectedTarget {
+			t.Errorf("assignment.Target is not %q. Got %q instead", tt.expectedTarget, assignment.Target.String())
+		}
+
+		if assignment.Operator != tt.expectedOperator {
+			t.Errorf("assignment.Operator is not %q. Got %q instead", tt.expectedOperator, assignment.Operator)
+		}
+
+		if assignment.Value.String() != tt.expectedValue {
+			t.Errorf("assignment.Value is not %q. Got %q instead", tt.expectedValue, assignment.Value.String())
+		}
+	}
+}
+
+func TestInvalidAssignmentTargets(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"1 = 2;", "Cannot assign to 1"},
+		{"f(x) = 2;", "Cannot assign to f(x)"},
+		{"a[1:2] = [3];", "Cannot assign to (a[1:2])"},
+		{"x + y += 1;", "Cannot assign to (x + y)"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+
+		errors := parser.GetErrors()
+		if len(errors) != 1 {
+			t.Errorf("Expected exactly 1 error for %q. Got %d instead: %v", tt.input, len(errors), errors)
+			continue
+		}
+
+		if errors[0].Code != INVALID_ASSIGNMENT || errors[0].Message != tt.expectedMessage {
+			t.Errorf("Wrong error for %q. Got %s[%s] %q instead", tt.input, errors[0].Severity, errors[0].Code, errors[0].Message)
+		}
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := `let add = fn(a, b) {
+  a + b
+};
+add(1, -2)`
+
+	parser := New(lexer.New(input))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	letStatement := program.Statements[0].(*ast.LetStatement)
+	function := letStatement.Value.(*ast.FunctionLiteral)
+	body := function.Body.Statements[0].(*ast.ExpressionStatement)
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+
+	tests := []struct {
+		node          ast.AstNode
+		expectedStart string
+		expectedEnd   string
+	}{
+		{program, "1:1", "4:11"},
+		{letStatement, "1:1", "3:2"},
+		{letStatement.Name, "1:5", "1:8"},
+		{function, "1:11", "3:2"},
+		{function.Body, "1:20", "3:2"},
+		{body, "2:3", "2:8"},
+		{call, "4:1", "4:11"},
+		{call.Arguments[1], "4:8", "4:10"},
+	}
+
+	for _, tt := range tests {
+		if tt.node.Pos().String() != tt.expectedStart {
+			t.Errorf("Pos() of %q is wrong. Expected %s, got %s instead", tt.node, tt.expectedStart, tt.node.Pos())
+		}
+
+		if tt.node.End().String() != tt.expectedEnd {
+			t.Errorf("End() of %q is wrong. Expected %s, got %s instead", tt.node, tt.expectedEnd, tt.node.End())
+		}
+	}
+}
+
 func testLetStatement(t *testing.T, statement ast.Statement, name string) bool {
 	if statement.TokenLiteral() != "let" {
 		t.Errorf("statement.TokenLiteral is not let. Got %s instead", statement.TokenLiteral())
@@ -414,6 +1247,8 @@ func testLiteralExpression(t *testing.T, expression ast.Expression, expected int
 		return testIntegerLiteral(t, expression, v)
 	case string:
 		return testIdentifier(t, expression, v)
+	case bool:
+		return testBooleanLiteral(t, expression, v)
 	}
 
 	t.Errorf("Type of expression is not handled. Got %T", expression)
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/ast"
+	"github.com/jpiechowka/micron-language-interpreter-go/token"
+)
+
+// scope tracks the names declared in the program, a function body or a loop body. It mirrors the environments the
+// evaluator creates, so assignments to constants can be reported before the code runs. Blocks of if expressions do
+// not open a scope, the same way they do not create an environment.
+type scope struct {
+	declarations map[string]declaration
+	outer        *scope
+}
+
+type declaration struct {
+	constant bool
+	pos      token.Position
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{declarations: make(map[string]declaration), outer: outer}
+}
+
+// resolve finds the declaration of the name in this scope or in the nearest enclosing scope declaring it
+func (scope *scope) resolve(name string) (declaration, bool) {
+	for current := scope; current != nil; current = current.outer {
+		if declared, ok := current.declarations[name]; ok {
+			return declared, true
+		}
+	}
+
+	return declaration{}, false
+}
+
+func (parser *Parser) openScope() {
+	parser.scope = newScope(parser.scope)
+}
+
+func (parser *Parser) closeScope() {
+	parser.scope = parser.scope.outer
+}
+
+// declare records the binding of the name in the current scope. A constant cannot be declared again in the scope
+// it belongs to, neither as a constant nor as a variable. Declaring the same name in an inner scope shadows it.
+func (parser *Parser) declare(name *ast.Identifier, constant bool) {
+	if previous, ok := parser.scope.declarations[name.Value]; ok && previous.constant {
+		parser.reportConstantError("Cannot redeclare constant %s", name, previous)
+		return
+	}
+
+	parser.scope.declarations[name.Value] = declaration{constant: constant, pos: name.Pos()}
+}
+
+// checkAssignable reports an assignment to the identifier if it refers to a constant. Names which are not declared
+// yet may still be defined before the assignment runs, e.g. by an earlier line in the REPL, so they are left to
+// the evaluator.
+func (parser *Parser) checkAssignable(name *ast.Identifier) {
+	if declared, ok := parser.scope.resolve(name.Value); ok && declared.constant {
+		parser.reportConstantError("Cannot assign to constant %s", name, declared)
+	}
+}
+
+func (parser *Parser) reportConstantError(format string, name *ast.Identifier, declared declaration) {
+	errorMsg := fmt.Sprintf(format, name.Value)
+	hint := fmt.Sprintf("%s was declared as a constant at %s", name.Value, declared.pos)
+	parser.reportError(CONSTANT_REASSIGNMENT, name.Pos(), name.End(), errorMsg, hint)
+}
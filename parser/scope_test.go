@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"github.com/jpiechowka/micron-language-interpreter-go/ast"
+	"github.com/jpiechowka/micron-language-interpreter-go/lexer"
+	"testing"
+)
+
+func TestConstStatement(t *testing.T) {
+	parser := New(lexer.New("const answer = 42;"))
+
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	statement, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.LetStatement. Got %T instead", program.Statements[0])
+	}
+
+	if !statement.IsConst() {
+		t.Errorf("statement.IsConst() is false for a const statement")
+	}
+
+	if statement.Name.Value != "answer" || !testIntegerLiteral(t, statement.Value, 42) {
+		t.Errorf("Wrong const statement. Got %s instead", statement)
+	}
+
+	if statement.String() != "const answer = 42;" {
+		t.Errorf("statement.String() is wrong. Got %q instead", statement.String())
+	}
+}
+
+func TestConstantRules(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string // empty if the input is valid
+		expectedPos     string
+	}{
+		// Constants cannot be assigned to, also not from nested scopes
+		{"const x = 1; x = 2;", "Cannot assign to constant x", "1:14"},
+		{"const x = 1; x += 2;", "Cannot assign to constant x", "1:14"},
+		{"const x = 1; let f = fn() { x = 2; };", "Cannot assign to constant x", "1:29"},
+		{"const x = 1; while (true) { x = 2; }", "Cannot assign to constant x", "1:29"},
+		{"const x = 1; if (true) { x = 2; }", "Cannot assign to constant x", "1:26"},
+
+		// A constant cannot be redeclared in its own scope, if blocks do not open a new scope
+		{"const x = 1; let x = 2;", "Cannot redeclare constant x", "1:18"},
+		{"const x = 1; const x = 2;", "Cannot redeclare constant x", "1:20"},
+		{"const x = 1; if (true) { let x = 2; }", "Cannot redeclare constant x", "1:30"},
+
+		// Inner scopes can shadow constants, the shadowing binding follows its own rules
+		{"const x = 1; let f = fn() { let x = 2; x = 3; };", "", ""},
+		{"const x = 1; let f = fn(x) { x = 3; };", "", ""},
+		{"const x = 1; for (x in [1, 2]) { x = 3; }", "", ""},
+		{"const x = 1; while (true) { let x = 2; x = 3; }", "", ""},
+		{"let f = fn() { const x = 1; }; let x = 2; x = 3;", "", ""},
+		{"let f = fn() { const y = 1; let g = fn() { y = 2; }; };", "Cannot assign to constant y", "1:44"},
+
+		// Variables can be turned into constants, but not the other way around
+		{"let x = 1; const x = 2; x = 3;", "Cannot assign to constant x", "1:25"},
+		{"let x = 1; let x = 2; x = 3;", "", ""},
+
+		// The value of a declaration still refers to the previous binding of the name
+		{"let x = 1; const y = x = 2;", "", ""},
+
+		// Elements of constant arrays and hashes are checked while evaluating
+		{"const a = [1]; a[0] = 2;", "", ""},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+
+		errors := parser.GetErrors()
+
+		if tt.expectedMessage == "" {
+			if len(errors) != 0 {
+				t.Errorf("Unexpected errors for %q: %v", tt.input, errors)
+			}
+			continue
+		}
+
+		if len(errors) != 1 {
+			t.Errorf("Expected exactly 1 error for %q. Got %d instead: %v", tt.input, len(errors), errors)
+			continue
+		}
+
+		if errors[0].Code != CONSTANT_REASSIGNMENT || errors[0].Message != tt.expectedMessage {
+			t.Errorf("Wrong error for %q. Got [%s] %q instead", tt.input, errors[0].Code, errors[0].Message)
+		}
+
+		if errors[0].Pos.String() != tt.expectedPos {
+			t.Errorf("Wrong error position for %q. Expected %s, got %s instead", tt.input, tt.expectedPos, errors[0].Pos)
+		}
+	}
+}
+
+func TestConstantErrorHint(t *testing.T) {
+	parser := New(lexer.New("const limit = 10;\nlimit = 20;"))
+	parser.ParseProgram()
+
+	errors := parser.GetErrors()
+	if len(errors) != 1 {
+		t.Fatalf("Expected exactly 1 error. Got %d instead: %v", len(errors), errors)
+	}
+
+	expectedHint := "limit was declared as a constant at 1:7"
+	if len(errors[0].Hints) != 1 || errors[0].Hints[0] != expectedHint {
+		t.Errorf("Wrong hints. Expected [%q], got %q instead", expectedHint, errors[0].Hints)
+	}
+}
@@ -3,8 +3,10 @@ package repl
 import (
 	"bufio"
 	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/evaluator"
 	"github.com/jpiechowka/micron-language-interpreter-go/lexer"
-	"github.com/jpiechowka/micron-language-interpreter-go/token"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+	"github.com/jpiechowka/micron-language-interpreter-go/parser"
 	"io"
 )
 
@@ -12,6 +14,7 @@ const PROMPT = ">>"
 
 func Start(input io.Reader, output io.Writer) {
 	scanner := bufio.NewScanner(input)
+	env := object.NewEnvironment()
 
 	for {
 		fmt.Fprint(output, PROMPT)
@@ -22,10 +25,17 @@ func Start(input io.Reader, output io.Writer) {
 		}
 
 		line := scanner.Text()
-		lex := lexer.New(line)
+		parser := parser.New(lexer.New(line))
 
-		for tok := lex.NextToken(); tok.TokenType != token.EOF; tok = lex.NextToken() {
-			fmt.Fprintf(output, "%+v\n", tok)
+		program := parser.ParseProgram()
+		if len(parser.GetErrors()) != 0 {
+			fmt.Fprint(output, parser.GetErrors().Render(line))
+			continue
+		}
+
+		evaluated := evaluator.Eval(program, env)
+		if evaluated != nil {
+			fmt.Fprintln(output, evaluated.Inspect())
 		}
 	}
 }
@@ -1,10 +1,53 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 type Token struct {
 	TokenType TokenType
 	Literal   string
+	Pos       Position // position of the first character of the token
+	End       Position // position immediately after the last character of the token
+
+	// Comments surrounding the token. They are not needed to parse the code, but keeping them allows tools
+	// like formatters to reproduce the source without losing them.
+	LeadingTrivia  []Trivia
+	TrailingTrivia []Trivia
+}
+
+type TriviaType string
+
+const (
+	LINE_COMMENT  = "LINE_COMMENT"  // line comment
+	BLOCK_COMMENT = "BLOCK_COMMENT" /* block comment */
+)
+
+// Trivia is a part of the source code which does not affect its meaning, e.g. a comment.
+type Trivia struct {
+	TriviaType TriviaType
+	Text       string // the source text, including comment delimiters
+	Pos        Position
+	End        Position
+}
+
+// Position describes a location in the source code. Offset is zero-based and counted in bytes,
+// Line and Column are one-based. The zero value is an invalid (unknown) position.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (position Position) IsValid() bool {
+	return position.Line > 0
+}
+
+func (position Position) String() string {
+	if !position.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", position.Line, position.Column)
 }
 
 const (
@@ -12,8 +55,10 @@ const (
 	EOF     = "EOF"
 
 	// Identifiers + literals
-	IDENT = "IDENT" // add, foobar, x, y, ...
-	INT   = "INT"   // 1343456
+	IDENT  = "IDENT"  // add, foobar, x, y, ...
+	INT    = "INT"    // 1343456
+	FLOAT  = "FLOAT"  // 3.14, 1.5e-3
+	STRING = "STRING" // "foo bar"
 
 	// Operators
 	ASSIGN   = "="
@@ -22,39 +67,80 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+	POWER    = "**"
+
+	// Compound assignment operators
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	PERCENT_ASSIGN  = "%="
+
+	// Bitwise operators
+	AMPERSAND   = "&"
+	PIPE        = "|"
+	CARET       = "^"
+	TILDE       = "~"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
 
 	// Equality and other math symbols
-	LESSTHAN    = "<"
-	GREATERTHAN = ">"
-	EQUALITY    = "=="
-	INEQUALITY  = "!="
+	LESSTHAN             = "<"
+	GREATERTHAN          = ">"
+	LESSTHAN_OR_EQUAL    = "<="
+	GREATERTHAN_OR_EQUAL = ">="
+	EQUALITY             = "=="
+	INEQUALITY           = "!="
+
+	// Logical operators
+	AND = "&&"
+	OR  = "||"
+
+	// Range operator, 0..10
+	DOT_DOT = ".."
 
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
 	LPAREN    = "("
 	RPAREN    = ")"
 	LBRACE    = "{"
 	RBRACE    = "}"
+	LBRACKET  = "["
+	RBRACKET  = "]"
 
 	// Reserved reservedKeywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
 )
 
 var reservedKeywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"const":    CONST,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
 func LookupIdentifier(identifier string) TokenType {
@@ -0,0 +1,22 @@
+package vm
+
+import (
+	"github.com/jpiechowka/micron-language-interpreter-go/code"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+)
+
+// Frame is the state of a single function call
+type Frame struct {
+	closure     *object.Closure
+	ip          int           // position of the current instruction
+	basePointer int           // stack pointer to restore when the call returns
+	scope       *object.Scope // innermost scope, changes when loop bodies are entered and left
+}
+
+func NewFrame(closure *object.Closure, basePointer int, scope *object.Scope) Frame {
+	return Frame{closure: closure, ip: -1, basePointer: basePointer, scope: scope}
+}
+
+func (frame *Frame) Instructions() code.Instructions {
+	return frame.closure.Fn.Instructions
+}
@@ -0,0 +1,53 @@
+package vm
+
+import (
+	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+)
+
+// iterator produces the values of a for loop. It stays on the stack for the duration of the loop.
+type iterator struct {
+	values      []object.Object // elements of an array or keys of a hash
+	position    int
+	rangeObject *object.Range // set instead of values when iterating over a range
+	current     int64
+}
+
+func (iter *iterator) Type() object.ObjectType { return "ITERATOR" }
+func (iter *iterator) Inspect() string         { return "iterator" }
+
+// newIterator iterates over the elements of an array, the keys of a hash in insertion order or the integers of a
+// range, like the for loop of the evaluator. The elements and keys are taken when the loop starts.
+func newIterator(iterable object.Object) (*iterator, error) {
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		return &iterator{values: iterable.Elements}, nil
+	case *object.Hash:
+		pairs := iterable.Pairs()
+		keys := make([]object.Object, len(pairs))
+		for i, pair := range pairs {
+			keys[i] = pair.Key
+		}
+		return &iterator{values: keys}, nil
+	case *object.Range:
+		return &iterator{rangeObject: iterable, current: iterable.Start}, nil
+	default:
+		return nil, fmt.Errorf("Cannot iterate over %s", iterable.Type())
+	}
+}
+
+func (iter *iterator) next() (object.Object, bool) {
+	if iter.rangeObject != nil {
+		if iter.current >= iter.rangeObject.End {
+			return nil, false
+		}
+		iter.current++
+		return &object.Integer{Value: iter.current - 1}, true
+	}
+
+	if iter.position >= len(iter.values) {
+		return nil, false
+	}
+	iter.position++
+	return iter.values[iter.position-1], true
+}
@@ -0,0 +1,480 @@
+package vm
+
+import (
+	"fmt"
+	"github.com/jpiechowka/micron-language-interpreter-go/ast"
+	"github.com/jpiechowka/micron-language-interpreter-go/code"
+	"github.com/jpiechowka/micron-language-interpreter-go/compiler"
+	"github.com/jpiechowka/micron-language-interpreter-go/evaluator"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+)
+
+// Limits of the VM, exceeding them is a stack overflow. The evaluator has no such limits, its recursion depth is only
+// limited by the Go stack, so programs recursing deeper than these limits allow only run in the evaluator.
+const (
+	StackSize = 1 << 16
+	MaxFrames = 1 << 20 // frames are allocated as needed, tail calls do not take any
+)
+
+// Operators of the opcodes whose semantics are shared with the evaluator
+var prefixOperators = map[code.Opcode]string{
+	code.OpMinus:  "-",
+	code.OpBang:   "!",
+	code.OpBitNot: "~",
+}
+
+var infixOperators = map[code.Opcode]string{
+	code.OpAdd:                "+",
+	code.OpSub:                "-",
+	code.OpMul:                "*",
+	code.OpDiv:                "/",
+	code.OpMod:                "%",
+	code.OpPow:                "**",
+	code.OpBitAnd:             "&",
+	code.OpBitOr:              "|",
+	code.OpBitXor:             "^",
+	code.OpShiftLeft:          "<<",
+	code.OpShiftRight:         ">>",
+	code.OpEqual:              "==",
+	code.OpNotEqual:           "!=",
+	code.OpLessThan:           "<",
+	code.OpLessThanOrEqual:    "<=",
+	code.OpGreaterThan:        ">",
+	code.OpGreaterThanOrEqual: ">=",
+	code.OpRange:              "..",
+}
+
+// VM runs compiled bytecode. Variables live in scopes on the heap rather than on the stack, so closures share the
+// variables they capture with the function that created them, like they do in the evaluator.
+type VM struct {
+	constants []object.Object
+
+	globals     []object.Object
+	globalNames []string
+
+	stack      []object.Object
+	sp         int // Always points to the next free slot, the top of the stack is stack[sp-1]
+	lastPopped object.Object
+
+	frames      []Frame
+	framesIndex int
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	mainFunction := &object.CompiledFunction{
+		Instructions: bytecode.Instructions,
+		Scopes:       bytecode.Scopes,
+		Calls:        bytecode.Calls,
+	}
+	mainClosure := &object.Closure{Fn: mainFunction}
+
+	frames := []Frame{NewFrame(mainClosure, 0, nil)} // The program has no scope of its own, its variables are globals
+
+	return &VM{
+		constants:   bytecode.Constants,
+		globals:     make([]object.Object, len(bytecode.Globals)),
+		globalNames: bytecode.Globals,
+		stack:       make([]object.Object, StackSize),
+		frames:      frames,
+		framesIndex: 1,
+	}
+}
+
+// LastPoppedStackElem returns the result of the program, i.e. the value of the last expression statement or the
+// value returned by a return statement at the top level. Like in the evaluator, a program ending with a let statement
+// has no result, so it is nil then.
+func (vm *VM) LastPoppedStackElem() object.Object {
+	return vm.lastPopped
+}
+
+func (vm *VM) Run() error {
+	frame := vm.currentFrame()
+	ins := frame.Instructions()
+
+	for frame.ip < len(ins)-1 {
+		frame.ip++
+		ip := frame.ip
+		op := code.Opcode(ins[ip])
+
+		var err error
+
+		switch op {
+		case code.OpConstant:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			err = vm.push(vm.constants[index])
+		case code.OpPop:
+			vm.lastPopped = vm.pop()
+		case code.OpTrue:
+			err = vm.push(evaluator.TRUE)
+		case code.OpFalse:
+			err = vm.push(evaluator.FALSE)
+		case code.OpNull:
+			err = vm.push(evaluator.NULL)
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod, code.OpPow, code.OpBitAnd, code.OpBitOr,
+			code.OpBitXor, code.OpShiftLeft, code.OpShiftRight, code.OpEqual, code.OpNotEqual, code.OpLessThan,
+			code.OpLessThanOrEqual, code.OpGreaterThan, code.OpGreaterThanOrEqual, code.OpRange:
+			err = vm.executeInfixOperation(op)
+		case code.OpMinus, code.OpBang, code.OpBitNot:
+			err = vm.pushResult(evaluator.PrefixOperation(prefixOperators[op], vm.pop()))
+
+		case code.OpJump:
+			frame.ip = int(code.ReadUint16(ins[ip+1:])) - 1
+		case code.OpJumpNotTruthy:
+			frame.ip += 2
+			if !evaluator.IsTruthy(vm.pop()) {
+				frame.ip = int(code.ReadUint16(ins[ip+1:])) - 1
+			}
+		case code.OpJumpTruthy:
+			frame.ip += 2
+			if evaluator.IsTruthy(vm.pop()) {
+				frame.ip = int(code.ReadUint16(ins[ip+1:])) - 1
+			}
+
+		case code.OpGetGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			err = vm.pushVariable(vm.globals[index], vm.globalNames[index])
+		case code.OpSetGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			err = vm.assignVariable(vm.globals, index, vm.globalNames[index])
+		case code.OpDefineGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			vm.globals[index] = vm.pop()
+			vm.lastPopped = nil // A let statement has no value, see LastPoppedStackElem
+		case code.OpGetLocal:
+			index := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+			err = vm.pushVariable(frame.scope.Slots[index], frame.scope.Names[index])
+		case code.OpSetLocal:
+			index := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+			err = vm.assignVariable(frame.scope.Slots, uint16(index), frame.scope.Names[index])
+		case code.OpDefineLocal:
+			index := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+			frame.scope.Slots[index] = vm.pop()
+		case code.OpGetFree:
+			scope := frame.scope.Up(int(code.ReadUint8(ins[ip+1:])))
+			index := code.ReadUint8(ins[ip+2:])
+			frame.ip += 2
+			err = vm.pushVariable(scope.Slots[index], scope.Names[index])
+		case code.OpSetFree:
+			scope := frame.scope.Up(int(code.ReadUint8(ins[ip+1:])))
+			index := code.ReadUint8(ins[ip+2:])
+			frame.ip += 2
+			err = vm.assignVariable(scope.Slots, uint16(index), scope.Names[index])
+		case code.OpCheckGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			err = checkVariable(vm.globals[index], vm.globalNames[index])
+		case code.OpCheckLocal:
+			index := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+			err = checkVariable(frame.scope.Slots[index], frame.scope.Names[index])
+		case code.OpCheckFree:
+			scope := frame.scope.Up(int(code.ReadUint8(ins[ip+1:])))
+			index := code.ReadUint8(ins[ip+2:])
+			frame.ip += 2
+			err = checkVariable(scope.Slots[index], scope.Names[index])
+
+		case code.OpEnterScope:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			frame.scope = object.NewScope(frame.closure.Fn.Scopes[index], frame.scope)
+		case code.OpLeaveScope:
+			frame.scope = frame.scope.Outer
+
+		case code.OpFreeze:
+			vm.stack[vm.sp-1] = object.Freeze(vm.stack[vm.sp-1])
+
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			elements := make([]object.Object, numElements)
+			copy(elements, vm.stack[vm.sp-numElements:vm.sp])
+			vm.sp -= numElements
+
+			err = vm.push(&object.Array{Elements: elements})
+		case code.OpHash:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			err = vm.buildHash(numElements)
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+			err = vm.pushResult(evaluator.IndexOperation(left, index))
+		case code.OpSetIndex:
+			value := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+			err = vm.pushResult(evaluator.IndexAssignment(left, index, value))
+		case code.OpUpdateIndex:
+			operator := infixOperators[code.Opcode(code.ReadUint8(ins[ip+1:]))]
+			frame.ip += 1
+
+			value := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+			err = vm.pushResult(evaluator.CompoundIndexAssignment(left, index, operator, value))
+		case code.OpSlice:
+			flags := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+
+			var low, high object.Object
+			if flags&code.SliceHigh != 0 {
+				high = vm.pop()
+			}
+			if flags&code.SliceLow != 0 {
+				low = vm.pop()
+			}
+			left := vm.pop()
+			err = vm.pushResult(evaluator.SliceOperation(left, low, high))
+
+		case code.OpIterInit:
+			var iter *iterator
+			if iter, err = newIterator(vm.pop()); err == nil {
+				err = vm.push(iter)
+			}
+		case code.OpIterNext:
+			frame.ip += 2
+			iter, ok := vm.stack[vm.sp-1].(*iterator)
+			if !ok {
+				err = fmt.Errorf("Not an iterator: %T", vm.stack[vm.sp-1])
+			} else if value, ok := iter.next(); ok {
+				err = vm.push(value)
+			} else {
+				vm.pop()
+				frame.ip = int(code.ReadUint16(ins[ip+1:])) - 1
+			}
+
+		case code.OpClosure:
+			index := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			if function, ok := vm.constants[index].(*object.CompiledFunction); ok {
+				err = vm.push(&object.Closure{Fn: function, Scope: frame.scope})
+			} else {
+				err = fmt.Errorf("Not a function: %s", vm.constants[index].Type())
+			}
+		case code.OpCall:
+			numArgs := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			if err = vm.callFunction(numArgs, frame.closure.Fn.Calls[ip]); err == nil {
+				frame = vm.currentFrame()
+				ins = frame.Instructions()
+			}
+		case code.OpReturnValue, code.OpReturn:
+			var returnValue object.Object = evaluator.NULL
+			if op == code.OpReturnValue {
+				returnValue = vm.pop()
+			}
+
+			if vm.framesIndex == 1 {
+				// A return statement at the top level ends the program
+				vm.lastPopped = returnValue
+				return nil
+			}
+
+			vm.framesIndex--
+			vm.sp = frame.basePointer
+			frame = vm.currentFrame()
+			ins = frame.Instructions()
+
+			err = vm.push(returnValue)
+		default:
+			err = fmt.Errorf("Unknown opcode: %d", op)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (vm *VM) executeInfixOperation(op code.Opcode) error {
+	right := vm.pop()
+	left := vm.pop()
+
+	// The most common operations are done right here, everything else is left to the evaluator
+	if leftInteger, ok := left.(*object.Integer); ok {
+		if rightInteger, ok := right.(*object.Integer); ok {
+			if result := integerOperation(op, leftInteger.Value, rightInteger.Value); result != nil {
+				return vm.push(result)
+			}
+		}
+	}
+
+	return vm.pushResult(evaluator.InfixOperation(infixOperators[op], left, right))
+}
+
+// integerOperation applies the operators which cannot fail, or returns nil for any other operator
+func integerOperation(op code.Opcode, left int64, right int64) object.Object {
+	switch op {
+	case code.OpAdd:
+		return &object.Integer{Value: left + right}
+	case code.OpSub:
+		return &object.Integer{Value: left - right}
+	case code.OpMul:
+		return &object.Integer{Value: left * right}
+	case code.OpEqual:
+		return evaluator.NativeBoolToBooleanObject(left == right)
+	case code.OpNotEqual:
+		return evaluator.NativeBoolToBooleanObject(left != right)
+	case code.OpLessThan:
+		return evaluator.NativeBoolToBooleanObject(left < right)
+	case code.OpLessThanOrEqual:
+		return evaluator.NativeBoolToBooleanObject(left <= right)
+	case code.OpGreaterThan:
+		return evaluator.NativeBoolToBooleanObject(left > right)
+	case code.OpGreaterThanOrEqual:
+		return evaluator.NativeBoolToBooleanObject(left >= right)
+	default:
+		return nil
+	}
+}
+
+// buildHash creates a hash from the keys and values on top of the stack, keeping the order of the keys
+func (vm *VM) buildHash(numElements int) error {
+	hash := object.NewHash()
+
+	for i := vm.sp - numElements; i < vm.sp; i += 2 {
+		key, ok := vm.stack[i].(object.Hashable)
+		if !ok {
+			return fmt.Errorf("Unusable as hash key: %s", vm.stack[i].Type())
+		}
+
+		hash.Set(key, vm.stack[i+1])
+	}
+	vm.sp -= numElements
+
+	return vm.push(hash)
+}
+
+// callFunction enters the closure below the arguments on top of the stack. The arguments are moved into the scope
+// of the call right away, so the frame of the call starts with the closure and the arguments removed. Errors are
+// located at the call expression like in the evaluator, if it is known.
+func (vm *VM) callFunction(numArgs int, call *ast.CallExpression) error {
+	var function, arguments ast.AstNode
+	if call != nil {
+		function, arguments = call.Function, call
+	}
+
+	callee := vm.stack[vm.sp-1-numArgs]
+	closure, ok := callee.(*object.Closure)
+	if !ok {
+		return newErrorAt(function, "Not a function: %s", callee.Type())
+	}
+
+	if numArgs != closure.Fn.NumParameters {
+		return newErrorAt(arguments, "Wrong number of arguments: expected %d, got %d", closure.Fn.NumParameters, numArgs)
+	}
+
+	scope := object.NewScope(closure.Fn.Scopes[0], closure.Scope)
+	copy(scope.Slots, vm.stack[vm.sp-numArgs:vm.sp])
+	vm.sp -= numArgs + 1
+
+	caller := vm.currentFrame()
+	if vm.framesIndex > 1 && returnsImmediately(caller.Instructions(), caller.ip+1) {
+		// The caller has nothing left to do but to return the result, so the call takes over its frame. This keeps
+		// the number of frames constant in tail recursion.
+		vm.sp = caller.basePointer
+		*caller = NewFrame(closure, caller.basePointer, scope)
+		return nil
+	}
+
+	if vm.framesIndex >= MaxFrames {
+		return fmt.Errorf("Stack overflow")
+	}
+
+	vm.frames = append(vm.frames[:vm.framesIndex], NewFrame(closure, vm.sp, scope))
+	vm.framesIndex++
+
+	return nil
+}
+
+// returnsImmediately reports whether the instruction at the position returns a value right away, possibly after
+// jumping to the end of if expressions
+func returnsImmediately(ins code.Instructions, position int) bool {
+	for position < len(ins) {
+		switch code.Opcode(ins[position]) {
+		case code.OpReturnValue:
+			return true
+		case code.OpJump:
+			position = int(code.ReadUint16(ins[position+1:]))
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+func (vm *VM) pushVariable(value object.Object, name string) error {
+	if value == nil {
+		return fmt.Errorf("Identifier not found: %s", name)
+	}
+	return vm.push(value)
+}
+
+// checkVariable fails unless the value of a variable about to be assigned is defined
+func checkVariable(value object.Object, name string) error {
+	if value == nil {
+		return fmt.Errorf("Assignment to undeclared identifier: %s", name)
+	}
+	return nil
+}
+
+// assignVariable stores the value on top of the stack in a slot, which must hold a defined variable
+func (vm *VM) assignVariable(slots []object.Object, index uint16, name string) error {
+	value := vm.pop()
+	if err := checkVariable(slots[index], name); err != nil {
+		return err
+	}
+
+	slots[index] = value
+	return nil
+}
+
+// pushResult pushes the result of an operation of the evaluator, or returns it as an error if it failed
+func (vm *VM) pushResult(result object.Object) error {
+	if err, ok := result.(*object.Error); ok {
+		return err
+	}
+	return vm.push(result)
+}
+
+// newErrorAt creates an error located at the node, or without a position if the node is nil
+func newErrorAt(node ast.AstNode, format string, a ...interface{}) *object.Error {
+	err := &object.Error{Message: fmt.Sprintf(format, a...)}
+	if node != nil {
+		err.Pos, err.End = node.Pos(), node.End()
+	}
+	return err
+}
+
+func (vm *VM) push(obj object.Object) error {
+	if vm.sp >= StackSize {
+		return fmt.Errorf("Stack overflow")
+	}
+
+	vm.stack[vm.sp] = obj
+	vm.sp++
+
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	obj := vm.stack[vm.sp-1]
+	vm.sp--
+	return obj
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return &vm.frames[vm.framesIndex-1]
+}
@@ -0,0 +1,323 @@
+package vm
+
+import (
+	"github.com/jpiechowka/micron-language-interpreter-go/compiler"
+	"github.com/jpiechowka/micron-language-interpreter-go/evaluator"
+	"github.com/jpiechowka/micron-language-interpreter-go/lexer"
+	"github.com/jpiechowka/micron-language-interpreter-go/object"
+	"github.com/jpiechowka/micron-language-interpreter-go/parser"
+	"testing"
+)
+
+type vmTestCase struct {
+	input    string
+	expected string // Inspect() of the result
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"1", "1"},
+		{"1 + 2", "3"},
+		{"50 / 2 * 2 + 10 - 5", "55"},
+		{"5 * (2 + 10)", "60"},
+		{"-50 + 100 + -50", "0"},
+		{"2 ** 3 ** 2", "512"},
+		{"7 % 3", "1"},
+		{"~5 & 0xff", "250"},
+		{"1 << 10 >> 2", "256"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"true", "true"},
+		{"1 < 2", "true"},
+		{"1 >= 2", "false"},
+		{"true == false", "false"},
+		{"(1 < 2) == true", "true"},
+		{"!5", "false"},
+		{"!!true", "true"},
+		{"1 && false", "false"},
+		{"false || 0", "true"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []vmTestCase{
+		{"if (true) { 10 }", "10"},
+		{"if (false) { 10 }", "null"},
+		{"if (1 > 2) { 10 } else { 20 }", "20"},
+		{"if (if (false) { 10 }) { 10 } else { 20 }", "20"},
+		{"if (true) { let a = 5; }", "null"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBindings(t *testing.T) {
+	tests := []vmTestCase{
+		{"let one = 1; one", "1"},
+		{"let one = 1; let two = one + one; one + two", "3"},
+		{"let x = 1; x = x + 1; x *= 10; x", "20"},
+		{"let a = [1, 2]; a[0] += 5; a", "[6, 2]"},
+		{`let h = {"a": 1}; h["b"] = 2; h["a"] -= 1; h`, "{a: 0, b: 2}"},
+		{"const c = 5; c", "5"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestCollections(t *testing.T) {
+	tests := []vmTestCase{
+		{"[]", "[]"},
+		{"[1, 2 * 2, 3 + 3]", "[1, 4, 6]"},
+		{"[1, 2, 3][-1]", "3"},
+		{"[1, 2, 3, 4][1:3]", "[2, 3]"},
+		{"[1, 2, 3][:-1]", "[1, 2]"},
+		{`{"b": 1, "a": 2}`, "{b: 1, a: 2}"},
+		{`{1: "one"}[1]`, "one"},
+		{`{}["missing"]`, "null"},
+		{"2..5", "2..5"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []vmTestCase{
+		{"let f = fn() { 5 + 10 }; f()", "15"},
+		{"let f = fn() { return 1; 2 }; f()", "1"},
+		{"let f = fn() { }; f()", "null"},
+		{"let add = fn(a, b) { a + b }; add(1, add(2, 3))", "6"},
+		{"let f = fn() { let a = 1; let b = 2; a + b }; f() + f()", "6"},
+		{"let adder = fn(x) { fn(y) { x + y } }; adder(2)(3)", "5"},
+		{"let counter = fn() { let n = 0; fn() { n += 1 } }; let c = counter(); c(); c(); c()", "3"},
+		{"let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(15)", "610"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestLoops(t *testing.T) {
+	tests := []vmTestCase{
+		{"let i = 0; while (i < 10) { i += 1; } i", "10"},
+		{"let s = 0; for (x in [1, 2, 3]) { s += x; } s", "6"},
+		{"let s = 0; for (i in 0..100) { if (i == 10) { break; } if (i % 2 == 0) { continue; } s += i; } s", "25"},
+		{`let keys = ""; for (k in {"b": 1, "a": 2}) { keys += k; } keys`, "ba"},
+		{"let f = fn() { for (i in 0..10) { if (i == 3) { return i; } } }; f()", "3"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []vmTestCase{
+		{"5 + true", "Type mismatch: INTEGER + BOOLEAN"},
+		{"missing", "Identifier not found: missing"},
+		{"missing = 1", "Assignment to undeclared identifier: missing"},
+		{"1 / 0", "Division by zero: 1 / 0"},
+		{"5()", "Not a function: INTEGER"},
+		{"fn(a) { a }()", "Wrong number of arguments: expected 1, got 0"},
+		{"[1][5]", "Index out of range: 5 with length 1"},
+		{"{[1]: 2}", "Unusable as hash key: ARRAY"},
+		{"for (x in 5) { x }", "Cannot iterate over INTEGER"},
+		{"const a = [1]; a[0] = 2", "Cannot modify frozen ARRAY"},
+		{`let h = {}; h["x"] += 1`, "Key not found: x"},
+		{"let f = fn(n) { 1 + f(n + 1) }; f(0)", "Stack overflow"},
+	}
+
+	for _, tt := range tests {
+		_, err := runVm(t, tt.input)
+		if err == nil {
+			t.Errorf("Running %q did not fail", tt.input)
+			continue
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("Wrong error for %q. Expected %q, got %q instead", tt.input, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []vmTestCase{
+		{"let countdown = fn(n) { if (n == 0) { 0 } else { countdown(n - 1) } }; countdown(1000000)", "0"},
+		{"let sum = fn(n, acc) { if (n == 0) { return acc; } return sum(n - 1, acc + n); }; sum(100000, 0)", "5000050000"},
+		{
+			"let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };" +
+				"let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } }; isEven(100001)",
+			"false",
+		},
+	}
+
+	runVmTests(t, tests)
+}
+
+// TestDifferential runs every program through the evaluator and the virtual machine and expects the same result,
+// or the same error message
+func TestDifferential(t *testing.T) {
+	programs := []string{
+		// Literals and operators
+		"5", "-5", "1.5 * 2", "7 / 2", "7.0 / 2", "7 % -3", "2 ** -1", "2 ** 62", "~0", "-1 >> 1", "1 << 63",
+		"1 < 2.5", "3 == 3.0", `"foo" + "bar"`, `"a" == "a"`, `"a" != "b"`, `"a" < "b"`, "if (false) { 1 } == if (false) { 2 }",
+		"true != false", "!if (false) { 1 }", "!0", "-true", "~1.5", `"a" - "b"`, "1 + if (false) { 1 }", "1 / 0", "1 % 0", "1.0 / 0",
+		"1 << -1", "1 << 64", "1..1", "3..1", "1..2.0",
+		// Logical operators
+		"true && 5", "0 && false", "false || if (false) { 1 }", "let x = 0; false && (x = 1); x", "let x = 0; true || (x = 1); x",
+		// Conditionals
+		"if (null) { 1 }", "if (0) { 1 } else { 2 }", "if (true) { } else { 1 }",
+		"let x = if (false) { 1 } else { if (true) { 2 } }; x",
+		// Bindings and assignments
+		"let a = 1; let a = a + 1; a", "let a = 5; a -= 2; a *= a; a", "let s = \"a\"; s += \"b\"; s",
+		"let a = 1; let b = (a = 5) + 1; a + b", "let a = [1, [2, 3]]; a[1][0] = 9; a", "let a = [1]; a[-1] = 2; a",
+		"let a = [1]; a[1] = 2", `let a = [1]; a["x"] = 2`, "let x = 5; x[0] = 1", `const h = {"a": [1]}; h["a"][0] = 2`,
+		"const a = [1]; let b = a[:]; b[0] = 2; b", "let a = [1]; const b = a; a[0] = 2; [a, b]",
+		"let a = [1]; const b = a; b[0] = 2", "let x = 1; const y = x; x = 2; y", "let x = 1; x = 2; const x = 3; x",
+		"missing += 1", "missing = 1 + true", "let f = fn() { let g = fn() { y -= 1 }; g(); let y = 1 }; f()",
+		"let f = fn() { if (false) { let y = 1; } y = [][0] }; f()", "let x = 1; x += 1 + true",
+		`let h = {}; h[[1]] = 1`, "let a = [1, 2]; a[0] %= 1; a[1] /= 2; a",
+		// Arrays, hashes, ranges
+		"[1, 2, 3][3]", "[1, 2, 3][-4]", "[][0]", `[1]["a"]`, "5[0]", "[1, 2, 3][1:]", "[1, 2, 3][-2:-1]",
+		"[1, 2, 3][2:1]", "[1, 2, 3][0:4]", `[1, 2][0:"a"]`, "5[1:]", `{"a": 1, "b": 2, "a": 3}`,
+		`{true: 1, false: 0}[1 < 2]`, `{1: 1}[fn() { 1 }]`, `let k = "x"; {k: 1, "y": k}`, "[1..3, 4]",
+		// Functions, closures and recursion
+		"fn(x) { x * 2 }(21)", "fn(a, b) { let c = a; c + b }", "let f = fn() { fn(x) { x } }; [f(), f]", "let f = fn(a, a) { a }; f(1, 2)",
+		"let compose = fn(f, g) { fn(x) { g(f(x)) } }; compose(fn(x) { x + 1 }, fn(x) { x * 2 })(5)",
+		"let map = fn(arr, f) { let out = arr[:]; for (i in 0..3) { out[i] = f(arr[i]); } out }; map([1, 2, 3], fn(x) { x * x })",
+		"let reduce = fn(arr, init, f) { let acc = init; for (x in arr) { acc = f(acc, x); } acc };" +
+			"reduce(1..6, 1, fn(a, b) { a * b })",
+		"let fact = fn(n) { if (n <= 1) { 1 } else { n * fact(n - 1) } }; fact(20)",
+		"let sum = fn(n) { if (n == 0) { 0 } else { n + sum(n - 1) } }; sum(20000)",
+		"let depth = fn(n) { if (n == 0) { return 0; } let d = depth(n - 1); d + 1 }; depth(100000)",
+		"let make = fn() { let a = 1; let get = fn() { a }; a = 2; get }; make()()",
+		"let f = fn() { let g = fn() { h() }; let h = fn() { 42 }; g() }; f()",
+		"let f = fn() { g() }; f()", "let f = fn() { g() }; let g = fn() { 7 }; f()",
+		"let x = 10; let f = fn() { x }; x = 20; f()", "let x = 1; let f = fn() { let x = 2; x }; f() + x",
+		"let f = fn(n) { if (n > 0) { return f(n - 1); } n }; f(10)", "let x = 1; let f = fn() { x = 2 }; f(); x",
+		"let f = fn() { if (true) { let y = 1; } y }; f()", "let f = fn() { if (false) { let y = 1; } y }; f()",
+		"let f = fn(x) { x }; f(1, 2)", `"str"()`, "let a = 1; a(1)", "let f = fn(g) { g(1) };\nf(fn() { 2 })",
+		"let f = fn(n) { if (n == 0) { 5(n) } else { f(n - 1) } }; f(3)", "[1,\n  2(3)]",
+		// Shadowing, the value of a let statement uses the binding of the enclosing scope
+		"let n = 5; let f = fn() { let n = n * 2; n }; f()", "let x = 1; let f = fn() { let y = x; let x = 2; y + x }; f()",
+		"let f = fn() { let y = x; let x = 2; y }; f()", "let f = fn(n) { let n = n + 1; n }; f(1)",
+		"let f = fn() { let g = fn() { n }; let n = 3; g() }; f()", "let n = 1; let f = fn() { let g = fn() { n }; let n = 3; g() }; f()",
+		"let x = 1; for (i in 0..3) { let x = x + i; } x", "let x = 1; let s = 0; for (i in 0..3) { let x = x + i; s += x; } s",
+		"let s = 0; let i = 0; while (i < 3) { i += 1; let i = i * 10; s += i; } s", "let y = 0; for (i in 0..2) { let z = y; let y = 5; y = z + y; } y",
+		// Loops
+		"let i = 0; while (i < 5) { i += 1; if (i == 3) { break; } } i",
+		"let n = 0; for (i in 0..3) { for (j in 0..3) { if (j == i) { continue; } n += 1; } } n",
+		"let n = 0; for (i in 0..5) { while (true) { n += 1; break; } if (i == 3) { break; } } n",
+		"let fs = [0, 0, 0]; for (i in 0..3) { fs[i] = fn() { i }; } [fs[0](), fs[1](), fs[2]()]",
+		"let fs = [0, 0, 0]; let i = 0; while (i < 3) { let j = i; fs[i] = fn() { j }; i += 1; } [fs[0](), fs[2]()]",
+		"let a = [1, 2]; let n = 0; for (x in a) { a[1] = 5; n += x; } n",
+		`let h = {"a": 1, "b": 2}; let s = ""; for (k in h) { s += k; } s`,
+		"let f = fn() { let s = 0; for (x in 1..10) { s += x; if (s > 10) { return s; } } -1 }; f()",
+		"let f = fn() { while (true) { for (x in [1]) { return x; } } }; f()",
+		"let total = 0; for (x in [1, 2, 3]) { let y = x * 2; total += y; } total",
+		"for (x in 3.5) { x }", "while (x) { 1 }",
+		// The value of a loop ending the program is null, a program ending with a let statement has no result
+		"while (false) {}", "1; for (x in [1]) { x }", "let i = 0; while (i < 3) { i += 1; }", "let x = 2",
+		"1; let x = 2", "let x = 2; x; let y = 3", "let x = 2; if (true) { let y = 3 }", "let x = 1; const y = x; x",
+		// Break and continue discarding the operands on the stack
+		"let n = 0; for (x in [1, 2]) { [1, if (true) { continue }]; n += 1; } n",
+		"let i = 0; while (true) { i += 1; 1 + if (i > 2) { break } } i",
+		"let i = 0; while (i < 100000) { i += 1; 1 + if (true) { continue } } i",
+		"let n = 0; for (x in 0..100000) { n += [x, {x: if (x > 1) { continue } }][0] } n",
+		"let f = fn(a, b) { a }; let r = 0; for (x in 0..5) { r = f(x, if (x == 2) { break } else { 0 }) } r",
+		"let a = [0]; for (x in 1..4) { a[0] += if (x == 2) { continue } else { x } } a",
+		"let n = 0; for (x in 0..3) { n += x + if (true) { for (y in 0..3) { [y, if (y == 1) { break }] } 10 } } n",
+		"let f = fn() { let y = if (true) { return 1 } else { 2 }; 5 }; f()",
+		// Top level return and errors stopping the program
+		"return 5; 6", "let f = fn() { 1 }; return f(); 2", "1 + true; 5", "let a = [1]; a[9]; a",
+	}
+
+	for _, input := range programs {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		expected := evaluator.Eval(program, object.NewEnvironment())
+
+		actual, err := runVm(t, input)
+
+		if expectedError, ok := expected.(*object.Error); ok {
+			// Inspect includes the position of the error, if it has one
+			if err == nil {
+				t.Errorf("Running %q did not fail. Expected %q, got %v instead", input, expectedError.Message, actual)
+			} else if runtimeError(err).Inspect() != expectedError.Inspect() {
+				t.Errorf("Wrong error for %q. Expected %q, got %q instead", input, expectedError.Inspect(), runtimeError(err).Inspect())
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Running %q failed: %s. Expected %s instead", input, err, inspect(expected))
+			continue
+		}
+
+		// Programs ending with a let statement have no result
+		if expected == nil || actual == nil {
+			if expected != actual {
+				t.Errorf("Wrong result for %q. Expected %s, got %s instead", input, inspect(expected), inspect(actual))
+			}
+			continue
+		}
+
+		if actual.Type() != expected.Type() || actual.Inspect() != expected.Inspect() {
+			t.Errorf("Wrong result for %q. Expected %s (%s), got %s (%s) instead",
+				input, expected.Inspect(), expected.Type(), actual.Inspect(), actual.Type())
+		}
+	}
+}
+
+// runtimeError converts an error of the VM to an error object, so it compares to the errors of the evaluator
+func runtimeError(err error) *object.Error {
+	if runtimeError, ok := err.(*object.Error); ok {
+		return runtimeError
+	}
+	return &object.Error{Message: err.Error()}
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "nil"
+	}
+	return obj.Inspect()
+}
+
+func runVmTests(t *testing.T, tests []vmTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		result, err := runVm(t, tt.input)
+		if err != nil {
+			t.Errorf("Running %q failed: %s", tt.input, err)
+			continue
+		}
+
+		if result.Inspect() != tt.expected {
+			t.Errorf("Wrong result for %q. Expected %s, got %s instead", tt.input, tt.expected, result.Inspect())
+		}
+	}
+}
+
+func runVm(t *testing.T, input string) (object.Object, error) {
+	t.Helper()
+
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.GetErrors()) != 0 {
+		t.Fatalf("Parser has errors for %q: %v", input, parser.GetErrors())
+	}
+
+	compiler := compiler.New()
+	if err := compiler.Compile(program); err != nil {
+		t.Fatalf("Compiler error for %q: %s", input, err)
+	}
+
+	vm := New(compiler.Bytecode())
+	err := vm.Run()
+
+	return vm.LastPoppedStackElem(), err
+}